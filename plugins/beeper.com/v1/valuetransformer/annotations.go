@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	AnnotationPrefix  = "valuetransformer.beeper.com/"
+	AnnotationSources = AnnotationPrefix + "sources"
+	AnnotationRegex   = AnnotationPrefix + "regex"
+	AnnotationSkip    = AnnotationPrefix + "skip"
+)
+
+// stripAnnotations removes the plugin annotations from the resource and drops empty annotations.
+func stripAnnotations(resource map[string]interface{}) {
+	metadata := getMap(resource, "metadata")
+
+	switch annotations := metadata["annotations"].(type) {
+	case map[string]interface{}:
+		for k := range annotations {
+			if strings.HasPrefix(k, AnnotationPrefix) {
+				delete(annotations, k)
+			}
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	case map[interface{}]interface{}:
+		for k := range annotations {
+			if ks, ok := k.(string); ok && strings.HasPrefix(ks, AnnotationPrefix) {
+				delete(annotations, k)
+			}
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
+
+func annotationSkip(meta *resourceMeta) (bool, error) {
+	value, ok := meta.annotations[AnnotationSkip]
+	if !ok {
+		return false, nil
+	}
+
+	skip, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for annotation %s: %s", AnnotationSkip, value)
+	}
+
+	return skip, nil
+}
+
+// annotationTransform returns the transform requested by the resource annotations, if any.
+func annotationTransform(meta *resourceMeta) *TransformConfig {
+	value, ok := meta.annotations[AnnotationSources]
+	if !ok {
+		return nil
+	}
+
+	transform := TransformConfig{Regex: meta.annotations[AnnotationRegex]}
+	for _, source := range strings.Split(value, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			transform.Sources = append(transform.Sources, source)
+		}
+	}
+
+	return &transform
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyTransformsAnnotations(t *testing.T) {
+	sources := map[string]map[string]interface{}{
+		"vars":  {"a": "1"},
+		"other": {"a": "2", "b": "3"},
+	}
+
+	tests := []struct {
+		name        string
+		annotations string
+		config      string
+		data        map[string]interface{}
+		metadata    map[string]interface{}
+		err         bool
+	}{
+		{
+			name:        "sources without config transforms",
+			annotations: "valuetransformer.beeper.com/sources: other",
+			config:      "{}",
+			data:        map[string]interface{}{"a": "2", "b": "3", "c": "<a>"},
+			metadata:    map[string]interface{}{"name": "app"},
+		},
+		{
+			name:        "sources after config transforms",
+			annotations: "valuetransformer.beeper.com/sources: ' vars, other '",
+			config:      "transforms: [{source: vars}]",
+			data:        map[string]interface{}{"a": "1", "b": "3", "c": "<a>"},
+			metadata:    map[string]interface{}{"name": "app"},
+		},
+		{
+			name:        "regex",
+			annotations: "valuetransformer.beeper.com/sources: other\n    valuetransformer.beeper.com/regex: '<(\\w+)>'",
+			config:      "{}",
+			data:        map[string]interface{}{"a": "${a}", "b": "${b}", "c": "2"},
+			metadata:    map[string]interface{}{"name": "app"},
+		},
+		{
+			name:        "skip",
+			annotations: "valuetransformer.beeper.com/skip: \"true\"\n    team: payments",
+			config:      "transforms: [{source: vars}]",
+			data:        map[string]interface{}{"a": "${a}", "b": "${b}", "c": "<a>"},
+			metadata:    map[string]interface{}{"name": "app", "annotations": map[string]interface{}{"team": "payments"}},
+		},
+		{
+			name:        "skip disabled",
+			annotations: "valuetransformer.beeper.com/skip: \"false\"",
+			config:      "transforms: [{source: vars}]",
+			data:        map[string]interface{}{"a": "1", "b": "${b}", "c": "<a>"},
+			metadata:    map[string]interface{}{"name": "app"},
+		},
+		{
+			name:        "invalid skip",
+			annotations: "valuetransformer.beeper.com/skip: maybe",
+			config:      "transforms: [{source: vars}]",
+			err:         true,
+		},
+		{
+			name:        "unknown annotation source",
+			annotations: "valuetransformer.beeper.com/sources: missing",
+			config:      "{}",
+			err:         true,
+		},
+	}
+
+	for _, test := range tests {
+		resource := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  annotations:\n    " + test.annotations + "\ndata:\n  a: ${a}\n  b: ${b}\n  c: <a>\n"
+
+		out, _, err := applyTestTransforms(t, resource, test.config, sources)
+		if test.err {
+			if _, ok := err.(*ResultError); !ok {
+				t.Errorf("%s: expected a result error, got %v", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		if data := getMap(out, "data"); !reflect.DeepEqual(data, test.data) {
+			t.Errorf("%s: got data %#v, want %#v", test.name, data, test.data)
+		}
+
+		if metadata := getMap(out, "metadata"); !reflect.DeepEqual(metadata, test.metadata) {
+			t.Errorf("%s: got metadata %#v, want %#v", test.name, metadata, test.metadata)
+		}
+	}
+}
+
+func TestStripAnnotations(t *testing.T) {
+	tests := []struct {
+		metadata map[string]interface{}
+		out      map[string]interface{}
+	}{
+		{
+			metadata: map[string]interface{}{"annotations": map[string]interface{}{AnnotationSources: "vars", "team": "payments"}},
+			out:      map[string]interface{}{"annotations": map[string]interface{}{"team": "payments"}},
+		},
+		{
+			metadata: map[string]interface{}{"annotations": map[string]interface{}{AnnotationSources: "vars", AnnotationSkip: "false"}},
+			out:      map[string]interface{}{},
+		},
+		{
+			metadata: map[string]interface{}{"annotations": map[interface{}]interface{}{AnnotationRegex: "x", "team": "payments"}},
+			out:      map[string]interface{}{"annotations": map[interface{}]interface{}{"team": "payments"}},
+		},
+		{
+			metadata: map[string]interface{}{"annotations": map[string]interface{}{}},
+			out:      map[string]interface{}{},
+		},
+		{
+			metadata: map[string]interface{}{},
+			out:      map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		resource := map[string]interface{}{"metadata": test.metadata}
+		stripAnnotations(resource)
+
+		if !reflect.DeepEqual(resource["metadata"], test.out) {
+			t.Errorf("stripAnnotations() = %#v, want %#v", resource["metadata"], test.out)
+		}
+	}
+}
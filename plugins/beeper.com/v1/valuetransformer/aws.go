@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// newAwsSession returns a session and client config honoring the optional awsRoleArn and awsRegion args.
+func newAwsSession(config *SourceConfig) (*session.Session, *aws.Config, error) {
+	awsRoleArn := getString(config.Args, "awsRoleArn")
+	awsRegion := getString(config.Args, "awsRegion")
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	creds := sess.Config.Credentials
+
+	if awsRoleArn != "" {
+		creds = stscreds.NewCredentials(sess, awsRoleArn)
+	}
+
+	var region *string = nil
+	if awsRegion != "" {
+		region = &awsRegion
+	}
+
+	return sess, &aws.Config{Credentials: creds, Region: region}, nil
+}
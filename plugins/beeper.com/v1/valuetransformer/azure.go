@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+const azureBlobHostSuffix = ".blob.core.windows.net"
+
+// newAzureBlobClient uses AZURE_STORAGE_CONNECTION_STRING if set, otherwise the default Azure credential chain.
+func newAzureBlobClient(serviceUrl string) (*azblob.Client, error) {
+	if connectionString := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connectionString != "" {
+		return azblob.NewClientFromConnectionString(connectionString, nil)
+	}
+
+	if serviceUrl == "" {
+		return nil, errors.New("no Azure storage account given")
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return azblob.NewClient(serviceUrl, cred, nil)
+}
+
+// readAzureBlob reads azblob://<container>/<blob>, https blob URLs are plain HTTP reads so SAS tokens keep working.
+func readAzureBlob(config *SourceConfig, u *url.URL) ([]byte, error) {
+	var serviceUrl string
+
+	account := getString(config.Args, "azureAccount")
+	if account == "" {
+		account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+	}
+	if account != "" {
+		serviceUrl = "https://" + account + azureBlobHostSuffix + "/"
+	}
+
+	container := u.Host
+	blob := strings.TrimPrefix(u.Path, "/")
+
+	client, err := newAzureBlobClient(serviceUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DownloadStream(context.Background(), container, blob, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the well known Azurite development account
+const testAzureAccount = "devstoreaccount1"
+const testAzureKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+// newTestAzureBlob serves blobs like Azurite at /<account>/<container>/<blob>.
+func newTestAzureBlob(t *testing.T, blobs map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/"+testAzureAccount+"/")]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(blob))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestConvertFileConfigAzureBlob(t *testing.T) {
+	server := newTestAzureBlob(t, map[string]string{"container/vars/app.yaml": "foo: bar\n"})
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "DefaultEndpointsProtocol=http;AccountName="+testAzureAccount+
+		";AccountKey="+testAzureKey+";BlobEndpoint="+server.URL+"/"+testAzureAccount+";")
+
+	out, err := convertFileConfig(&SourceConfig{Type: "File", Args: map[string]interface{}{"path": "azblob://container/vars/app.yaml"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, map[string]interface{}{"foo": "bar"}) {
+		t.Errorf("got %#v", out)
+	}
+
+	if _, err := convertFileConfig(&SourceConfig{Type: "File", Args: map[string]interface{}{"path": "azblob://container/missing.yaml"}}); err == nil {
+		t.Errorf("expected an error for a missing blob")
+	}
+}
+
+func TestConvertFileConfigSasUrl(t *testing.T) {
+	// SAS URLs carry their authorization in the query and are read over plain HTTP
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/container/app.yaml" || r.URL.Query().Get("sig") != "signature" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("foo: sas\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+
+	out, err := convertFileConfig(&SourceConfig{Type: "File", Args: map[string]interface{}{
+		"path": server.URL + "/container/app.yaml?sv=2021-08-06&sr=b&sig=signature",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, map[string]interface{}{"foo": "sas"}) {
+		t.Errorf("got %#v", out)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const cacheKeyFile = "cache.key"
+
+// SourceCache is a content addressed on-disk cache of source values, entries are encrypted at rest.
+type SourceCache struct {
+	dir string
+	ttl time.Duration
+	key []byte
+
+	// external is set when the key is given by VALUETRANSFORMER_CACHE_KEY, required to cache secrets
+	external bool
+}
+
+type cacheEntry struct {
+	Type string                 `yaml:"type"`
+	Args map[string]interface{} `yaml:"args"`
+	Env  map[string]string      `yaml:"env,omitempty"`
+}
+
+// cacheEnvVars select the account, region, server or identity sources read from
+var cacheEnvVars = []string{
+	"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID",
+	"AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE",
+	"VAULT_ADDR", "VAULT_NAMESPACE", "VAULT_TOKEN",
+	"KUBECONFIG",
+	"TFE_TOKEN", "CONSUL_HTTP_ADDR", "CONSUL_HTTP_TOKEN",
+	"GOOGLE_APPLICATION_CREDENTIALS", "CLOUDSDK_CORE_PROJECT", "STORAGE_EMULATOR_HOST",
+	"AZURE_STORAGE_ACCOUNT", "AZURE_STORAGE_CONNECTION_STRING", "AZURE_CLIENT_ID", "AZURE_TENANT_ID",
+}
+
+// cacheEnvironment returns the environment that selects where a source is read from, it is part of the cache key.
+func cacheEnvironment(source *SourceConfig) map[string]string {
+	env := make(map[string]string)
+
+	for _, name := range cacheEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "TF_TOKEN_") {
+			name, value, _ := strings.Cut(kv, "=")
+			env[name] = value
+		}
+	}
+
+	// the kubeconfig context can change without any environment change
+	if source.Type == "KubernetesObject" {
+		env["kubeContext"] = kubernetesContextIdentity(source)
+	}
+
+	return env
+}
+
+// isCachedSource checks if a source is cached by default, remote sources are cached unless disabled.
+func isCachedSource(source *SourceConfig) bool {
+	if source.Cache != nil {
+		return *source.Cache
+	}
+
+	switch source.Type {
+	case "SecretsManager", "ParameterStore", "Vault", "KubernetesObject":
+		return true
+	case "TerraformState":
+		if backend := getString(source.Args, "backend"); backend != "" && backend != "local" {
+			return true
+		}
+		fallthrough
+	case "File":
+		u, err := url.Parse(getString(source.Args, "path"))
+		return err == nil && u.Scheme != ""
+	default:
+		return false
+	}
+}
+
+// isSecretSource checks if a source returns secrets which are only cached with an external key.
+func isSecretSource(source *SourceConfig) bool {
+	switch source.Type {
+	case "SecretsManager", "ParameterStore", "Vault", "KubernetesObject", "Exec":
+		return true
+	default:
+		return source.secret
+	}
+}
+
+// hasSensitive checks if any source value is sensitive.
+func hasSensitive(vars map[string]interface{}) bool {
+	for _, v := range vars {
+		if _, ok := v.(Sensitive); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allows checks if the cache can store secrets of a source.
+func (c *SourceCache) allows(source *SourceConfig, vars map[string]interface{}) bool {
+	return c.external || (!isSecretSource(source) && !hasSensitive(vars))
+}
+
+// allowsBody checks if the cache can store raw responses of a source, raw Terraform states contain
+// sensitive outputs before they are marked.
+func (c *SourceCache) allowsBody(source *SourceConfig) bool {
+	return c.external || (!isSecretSource(source) && source.Type != "TerraformState")
+}
+
+// newSourceCache returns the configured cache or nil if caching is disabled.
+func newSourceCache(config *CacheConfig) (*SourceCache, error) {
+	mode := strings.ToLower(os.Getenv("VALUETRANSFORMER_CACHE"))
+
+	if config.Ttl == "" && mode != "purge" {
+		return nil, nil
+	}
+
+	dir := os.ExpandEnv(config.Dir)
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(userCache, "valuetransformer")
+	}
+
+	switch mode {
+	case "0", "false", "off", "bypass":
+		return nil, nil
+	case "purge":
+		if DebugEnabled {
+			fmt.Fprintf(os.Stderr, "Purging cache %s\n", dir)
+		}
+		if err := purgeCache(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Ttl == "" {
+		return nil, nil
+	}
+
+	ttl, err := time.ParseDuration(config.Ttl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache ttl: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	key, external, err := loadCacheKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SourceCache{dir: dir, ttl: ttl, key: key, external: external}, nil
+}
+
+// isCacheEntry checks if a file name is an entry or temporary file written by the cache.
+func isCacheEntry(name string) bool {
+	if strings.HasPrefix(name, ".tmp-") {
+		return true
+	}
+
+	name = strings.TrimPrefix(name, "etag-")
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// purgeCache removes the entries of the cache, other files in the directory are kept.
+func purgeCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isCacheEntry(entry.Name()) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadCacheKey uses VALUETRANSFORMER_CACHE_KEY or a random key generated in the user config directory,
+// the key is kept out of the cache directory so the cache can't be decrypted by itself.
+func loadCacheKey() ([]byte, bool, error) {
+	if env := os.Getenv("VALUETRANSFORMER_CACHE_KEY"); env != "" {
+		key := sha256.Sum256([]byte(env))
+		return key[:], true, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, false, err
+	}
+
+	dir := filepath.Join(configDir, "valuetransformer")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, false, err
+	}
+
+	path := filepath.Join(dir, cacheKeyFile)
+
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) == 32 {
+		return key, false, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, false, err
+	}
+
+	if err := writeFileAtomic(path, key); err != nil {
+		return nil, false, err
+	}
+
+	return key, false, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Key returns the cache key of the source type, its expanded args and the environment selecting its origin.
+func (c *SourceCache) Key(source *SourceConfig) (string, error) {
+	data, err := yaml.Marshal(cacheEntry{Type: source.Type, Args: source.Args, Env: cacheEnvironment(source)})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *SourceCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// read returns the decrypted entry, entries older than maxAge are ignored unless it is zero.
+func (c *SourceCache) read(key string, maxAge time.Duration) ([]byte, bool) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil || (maxAge > 0 && time.Since(info.ModTime()) > maxAge) {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	plain, err := c.decrypt(data)
+	if err != nil {
+		if DebugEnabled {
+			fmt.Fprintf(os.Stderr, "Ignoring unreadable cache entry %s: %s\n", key, err)
+		}
+		return nil, false
+	}
+
+	return plain, true
+}
+
+func (c *SourceCache) write(key string, plain []byte) error {
+	data, err := c.encrypt(plain)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.path(key), data)
+}
+
+// Get returns the cached values if they exist and have not expired.
+func (c *SourceCache) Get(key string) (map[string]interface{}, bool) {
+	plain, ok := c.read(key, c.ttl)
+	if !ok {
+		return nil, false
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(plain, &doc); err != nil || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	vars := make(map[string]interface{})
+	content := doc.Content[0].Content
+	for i := 0; i+1 < len(content); i += 2 {
+		value, err := decodeSensitiveNode(content[i+1])
+		if err != nil {
+			return nil, false
+		}
+		vars[content[i].Value] = value
+	}
+
+	return vars, true
+}
+
+// Put stores the values in the cache.
+func (c *SourceCache) Put(key string, vars map[string]interface{}) error {
+	plain, err := yaml.Marshal(vars)
+	if err != nil {
+		return err
+	}
+
+	return c.write(key, plain)
+}
+
+func (c *SourceCache) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *SourceCache) encrypt(plain []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (c *SourceCache) decrypt(data []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("cache entry too short")
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
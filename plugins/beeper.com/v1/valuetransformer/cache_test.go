@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsCacheEntry(t *testing.T) {
+	hash := strings.Repeat("0f", 32)
+
+	tests := []struct {
+		name  string
+		entry bool
+	}{
+		{name: hash, entry: true},
+		{name: "etag-" + hash, entry: true},
+		{name: ".tmp-123456", entry: true},
+		{name: hash[:62], entry: false},
+		{name: strings.Repeat("zz", 32), entry: false},
+		{name: "etag-abc", entry: false},
+		{name: "cache.key", entry: false},
+		{name: ".bashrc", entry: false},
+	}
+
+	for _, test := range tests {
+		if entry := isCacheEntry(test.name); entry != test.entry {
+			t.Errorf("isCacheEntry(%s) = %t, want %t", test.name, entry, test.entry)
+		}
+	}
+}
+
+func TestPurgeCache(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	dir := writeTestFiles(t, map[string]string{
+		hash:            "entry",
+		"etag-" + hash:  "etag",
+		"cache.key":     "key",
+		"notes.txt":     "keep",
+		"sub/" + hash:   "nested",
+		".tmp-leftover": "tmp",
+	})
+
+	t.Setenv("VALUETRANSFORMER_CACHE", "purge")
+	t.Setenv("VALUETRANSFORMER_CACHE_KEY", "test")
+
+	cache, err := newSourceCache(&CacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache != nil {
+		t.Errorf("purge without a ttl should not enable the cache")
+	}
+
+	for _, name := range []string{hash, "etag-" + hash, ".tmp-leftover"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been purged", name)
+		}
+	}
+
+	for _, name := range []string{"cache.key", "notes.txt", "sub/" + hash} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should have been kept: %s", name, err)
+		}
+	}
+
+	if _, err := newSourceCache(&CacheConfig{Dir: filepath.Join(dir, "missing")}); err != nil {
+		t.Errorf("purging a missing cache failed: %s", err)
+	}
+}
+
+func TestSourceCacheRoundTrip(t *testing.T) {
+	t.Setenv("VALUETRANSFORMER_CACHE", "")
+	t.Setenv("VALUETRANSFORMER_CACHE_KEY", "test")
+
+	dir := t.TempDir()
+	cache, err := newSourceCache(&CacheConfig{Dir: dir, Ttl: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := SourceConfig{Type: "Vault", Args: map[string]interface{}{"path": "app"}}
+	key, err := cache.Key(&source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Put(key, map[string]interface{}{"password": Sensitive{"hunter2"}, "port": 5432}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("cache entry is not encrypted")
+	}
+
+	vars, ok := cache.Get(key)
+	if !ok {
+		t.Fatal("cached entry not found")
+	}
+	if vars["password"] != (Sensitive{"hunter2"}) || vars["port"] != 5432 {
+		t.Errorf("got %#v", vars)
+	}
+
+	t.Setenv("AWS_PROFILE", "other")
+	if other, err := cache.Key(&source); err != nil || other == key {
+		t.Errorf("cache key should change with the environment, got %s, %v", other, err)
+	}
+
+	t.Setenv("VALUETRANSFORMER_CACHE_KEY", "other")
+	other, err := newSourceCache(&CacheConfig{Dir: dir, Ttl: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.Get(key); ok {
+		t.Errorf("entry should not be readable with another key")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	EncodingBase64 = "base64"
+	EncodingPlain  = "plain"
+)
+
+// DefaultEncodedFields are always applied before the configured encoded fields.
+var DefaultEncodedFields = []EncodedField{
+	{Kind: "Secret", Path: "data", Encoding: EncodingBase64},
+	{Kind: "Secret", Path: "stringData", Encoding: EncodingPlain},
+	{Kind: "ConfigMap", Path: "binaryData", Encoding: EncodingBase64},
+}
+
+// encodedFieldsFor returns the encoded fields for a kind, later entries take precedence.
+func encodedFieldsFor(config *TransformerConfig, kind string) ([]EncodedField, error) {
+	out := []EncodedField{}
+
+	for _, field := range append(append([]EncodedField{}, DefaultEncodedFields...), config.EncodedFields...) {
+		if field.Kind != "" && field.Kind != kind {
+			continue
+		}
+
+		switch field.Encoding {
+		case "":
+			field.Encoding = EncodingBase64
+		case EncodingBase64, EncodingPlain:
+		default:
+			return nil, fmt.Errorf("unsupported encoding '%s' for %s %s", field.Encoding, field.Kind, field.Path)
+		}
+
+		var err error
+		if field.segments, err = parseFieldPath(field.Path); err != nil {
+			return nil, err
+		}
+
+		out = append(out, field)
+	}
+
+	return out, nil
+}
+
+// fieldEncoding returns the encoding for values under the path.
+func fieldEncoding(fields []EncodedField, path []string) string {
+	encoding := EncodingPlain
+
+	for _, f := range fields {
+		if len(path) > len(f.segments) && matchFieldPath(f.segments, path) {
+			encoding = f.Encoding
+		}
+	}
+
+	return encoding
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestApplyTransformsEncodedFields(t *testing.T) {
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	sources := map[string]map[string]interface{}{"vars": {"password": "hunter2"}}
+
+	tests := []struct {
+		name     string
+		resource string
+		config   string
+		field    []string
+		out      map[string]interface{}
+		err      bool
+	}{
+		{
+			name:     "secret data",
+			resource: "apiVersion: v1\nkind: Secret\nmetadata: {name: app}\ndata:\n  password: " + b64("${password}") + "\n  plain: " + b64("keep") + "\n",
+			config:   "transforms: [{source: vars}]",
+			field:    []string{"data"},
+			out:      map[string]interface{}{"password": b64("hunter2"), "plain": b64("keep")},
+		},
+		{
+			name:     "secret stringData",
+			resource: "apiVersion: v1\nkind: Secret\nmetadata: {name: app}\nstringData:\n  password: ${password}\n",
+			config:   "transforms: [{source: vars}]",
+			field:    []string{"stringData"},
+			out:      map[string]interface{}{"password": "hunter2"},
+		},
+		{
+			name:     "configmap binaryData",
+			resource: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app}\nbinaryData:\n  password: " + b64("${password}") + "\n",
+			config:   "transforms: [{source: vars}]",
+			field:    []string{"binaryData"},
+			out:      map[string]interface{}{"password": b64("hunter2")},
+		},
+		{
+			name:     "configured field",
+			resource: "apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata: {name: app}\nspec:\n  template:\n    data:\n      password: " + b64("${password}") + "\n",
+			config:   "encodedFields: [{kind: SealedSecret, path: spec.template.data}]\ntransforms: [{source: vars}]",
+			field:    []string{"spec", "template", "data"},
+			out:      map[string]interface{}{"password": b64("hunter2")},
+		},
+		{
+			name:     "default overridden with plain",
+			resource: "apiVersion: v1\nkind: Secret\nmetadata: {name: app}\ndata:\n  password: ${password}\n",
+			config:   "encodedFields: [{kind: Secret, path: data, encoding: plain}]\ntransforms: [{source: vars}]",
+			field:    []string{"data"},
+			out:      map[string]interface{}{"password": "hunter2"},
+		},
+		{
+			name:     "other kinds are not decoded",
+			resource: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app}\ndata:\n  password: ${password}\n",
+			config:   "encodedFields: [{kind: SealedSecret, path: data}]\ntransforms: [{source: vars}]",
+			field:    []string{"data"},
+			out:      map[string]interface{}{"password": "hunter2"},
+		},
+		{
+			name:     "invalid base64",
+			resource: "apiVersion: v1\nkind: Secret\nmetadata: {name: app}\ndata:\n  password: ${password}\n",
+			config:   "transforms: [{source: vars}]",
+			err:      true,
+		},
+		{
+			name:     "unsupported encoding",
+			resource: "apiVersion: v1\nkind: Secret\nmetadata: {name: app}\n",
+			config:   "encodedFields: [{kind: Secret, path: data, encoding: hex}]\ntransforms: [{source: vars}]",
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		out, _, err := applyTestTransforms(t, test.resource, test.config, sources)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		value, _ := kubernetesObjectField(out, test.field)
+		if !reflect.DeepEqual(value, test.out) {
+			t.Errorf("%s: got %#v, want %#v", test.name, value, test.out)
+		}
+	}
+}
+
+func TestFieldEncoding(t *testing.T) {
+	fields, err := encodedFieldsFor(&TransformerConfig{EncodedFields: []EncodedField{{Path: "spec.data[*]"}}}, "Secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     []string
+		encoding string
+	}{
+		{path: []string{"data", "password"}, encoding: EncodingBase64},
+		{path: []string{"data"}, encoding: EncodingPlain},
+		{path: []string{"stringData", "password"}, encoding: EncodingPlain},
+		{path: []string{"spec", "data", "[0]", "value"}, encoding: EncodingBase64},
+		{path: []string{"spec", "other"}, encoding: EncodingPlain},
+	}
+
+	for _, test := range tests {
+		if encoding := fieldEncoding(fields, test.path); encoding != test.encoding {
+			t.Errorf("fieldEncoding(%q) = %s, want %s", test.path, encoding, test.encoding)
+		}
+	}
+}
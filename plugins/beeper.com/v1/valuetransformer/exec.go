@@ -11,7 +11,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func convertExecConfig(config *SourceConfig) map[string]string {
+func convertExecConfig(config *SourceConfig) (map[string]interface{}, error) {
 	buffer := bytes.Buffer{}
 
 	var path string
@@ -33,12 +33,12 @@ func convertExecConfig(config *SourceConfig) map[string]string {
 		}
 		var err error
 		if path, err = exec.LookPath(command[0]); err != nil {
-			panic(err)
+			return nil, err
 		}
 	case []string:
 		command = c
 	default:
-		panic(errors.New("missing command for exec"))
+		return nil, errors.New("missing command for exec")
 	}
 
 	process := exec.Cmd{
@@ -49,16 +49,15 @@ func convertExecConfig(config *SourceConfig) map[string]string {
 	}
 
 	if err := process.Run(); err != nil {
-		fmt.Println("error running", process.Path, process.Args)
-		panic(err)
+		return nil, fmt.Errorf("error running %s %v: %w", process.Path, process.Args, err)
 	}
 
 	var raw map[string]interface{}
 	if err := yaml.Unmarshal(buffer.Bytes(), &raw); err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	flat := make(map[string]string)
+	flat := make(map[string]interface{})
 	flattenToMap(raw, "", flat)
-	return flat
+	return flat, nil
 }
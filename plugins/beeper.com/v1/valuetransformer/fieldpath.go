@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseFieldPath parses a kyaml style field path like spec.containers[*].env[0].value to segments,
+// list indices are kept as bracketed segments and * matches any single key or index.
+func parseFieldPath(s string) ([]string, error) {
+	segments := []string{}
+
+	for _, part := range strings.Split(s, ".") {
+		key := part
+		index := ""
+
+		if open := strings.Index(part, "["); open >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid field path '%s'", s)
+			}
+			key = part[:open]
+			index = part[open:]
+		}
+
+		if key != "" {
+			segments = append(segments, key)
+		} else if index == "" {
+			return nil, fmt.Errorf("empty segment in field path '%s'", s)
+		}
+
+		for len(index) > 0 {
+			end := strings.Index(index, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path '%s'", s)
+			}
+
+			i := index[1:end]
+			if _, err := strconv.Atoi(i); err != nil && i != "*" {
+				return nil, fmt.Errorf("invalid list index '%s' in field path '%s'", i, s)
+			}
+
+			segments = append(segments, index[:end+1])
+			index = index[end+1:]
+		}
+	}
+
+	return segments, nil
+}
+
+func parseFieldPaths(paths []string) ([][]string, error) {
+	out := make([][]string, len(paths))
+	for i, p := range paths {
+		var err error
+		if out[i], err = parseFieldPath(p); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func isIndexSegment(segment string) bool {
+	return strings.HasPrefix(segment, "[")
+}
+
+// matchFieldPath checks if the pattern matches the path or any of its parents.
+func matchFieldPath(pattern []string, path []string) bool {
+	if len(pattern) > len(path) {
+		return false
+	}
+
+	for i, p := range pattern {
+		switch {
+		case p == "*":
+			if isIndexSegment(path[i]) {
+				return false
+			}
+		case p == "[*]":
+			if !isIndexSegment(path[i]) {
+				return false
+			}
+		case p != path[i]:
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchAnyFieldPath(patterns [][]string, path []string) bool {
+	for _, pattern := range patterns {
+		if matchFieldPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// childPath returns a copy of the path with a key or list index appended.
+func childPath(path []string, segment string) []string {
+	out := make([]string, len(path)+1)
+	copy(out, path)
+	out[len(path)] = segment
+	return out
+}
+
+func indexSegment(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+// fieldPath converts the path segments to a dot separated field path.
+func fieldPath(path []string) string {
+	var b strings.Builder
+
+	for i, segment := range path {
+		if i > 0 && !isIndexSegment(segment) {
+			b.WriteString(".")
+		}
+		b.WriteString(segment)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		segments []string
+		err      bool
+	}{
+		{path: "data", segments: []string{"data"}},
+		{path: "spec.template.metadata", segments: []string{"spec", "template", "metadata"}},
+		{path: "spec.containers[*].env[0].value", segments: []string{"spec", "containers", "[*]", "env", "[0]", "value"}},
+		{path: "data.*", segments: []string{"data", "*"}},
+		{path: "items[1][2]", segments: []string{"items", "[1]", "[2]"}},
+		{path: "[0].name", segments: []string{"[0]", "name"}},
+		{path: "spec..name", err: true},
+		{path: "spec.containers[0", err: true},
+		{path: "spec.containers[a]", err: true},
+		{path: "spec.containers[0]x", err: true},
+	}
+
+	for _, test := range tests {
+		segments, err := parseFieldPath(test.path)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseFieldPath(%q) expected an error, got %q", test.path, segments)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseFieldPath(%q) failed: %s", test.path, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(segments, test.segments) {
+			t.Errorf("parseFieldPath(%q) = %q, want %q", test.path, segments, test.segments)
+		}
+
+		if path := fieldPath(segments); path != test.path {
+			t.Errorf("fieldPath(%q) = %q, want %q", segments, path, test.path)
+		}
+	}
+}
+
+func TestMatchFieldPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    []string
+		match   bool
+	}{
+		{pattern: "data", path: []string{"data", "key"}, match: true},
+		{pattern: "data.key", path: []string{"data", "key"}, match: true},
+		{pattern: "data.key", path: []string{"data"}, match: false},
+		{pattern: "data.other", path: []string{"data", "key"}, match: false},
+		{pattern: "data.*", path: []string{"data", "key"}, match: true},
+		{pattern: "spec.*", path: []string{"spec", "[0]"}, match: false},
+		{pattern: "spec.containers[*].env", path: []string{"spec", "containers", "[3]", "env", "[0]", "value"}, match: true},
+		{pattern: "spec.containers[*]", path: []string{"spec", "containers", "name"}, match: false},
+		{pattern: "spec.containers[1]", path: []string{"spec", "containers", "[0]", "image"}, match: false},
+		{pattern: "spec.containers[0]", path: []string{"spec", "containers", "[0]", "image"}, match: true},
+	}
+
+	for _, test := range tests {
+		pattern, err := parseFieldPath(test.pattern)
+		if err != nil {
+			t.Fatalf("parseFieldPath(%q) failed: %s", test.pattern, err)
+		}
+
+		if match := matchFieldPath(pattern, test.path); match != test.match {
+			t.Errorf("matchFieldPath(%q, %q) = %t, want %t", test.pattern, test.path, match, test.match)
+		}
+	}
+}
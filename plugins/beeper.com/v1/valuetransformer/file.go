@@ -1,30 +1,36 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"gopkg.in/yaml.v3"
 )
 
-func readYamlFile(path string, dest interface{}) {
+func readYamlFile(path string, dest interface{}) error {
 	configFile, err := os.Open(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer configFile.Close()
 
 	configDecoder := yaml.NewDecoder(configFile)
 	if err := configDecoder.Decode(dest); err != nil {
-		panic(err)
+		return fmt.Errorf("failed to parse %s: %w", path, err)
 	}
+
+	return nil
+}
+
+// isRemotePath returns true for paths with a URL scheme, single letters are left for Windows drives.
+func isRemotePath(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && len(u.Scheme) > 1
 }
 
 func readFile(config *SourceConfig) ([]byte, error) {
@@ -33,7 +39,7 @@ func readFile(config *SourceConfig) ([]byte, error) {
 	case string:
 		path = p
 	default:
-		panic(errors.New("path missing from file type source"))
+		return nil, errors.New("path missing from file type source")
 	}
 
 	u, err := url.Parse(path)
@@ -44,22 +50,12 @@ func readFile(config *SourceConfig) ([]byte, error) {
 
 	switch u.Scheme {
 	case "s3":
-		awsRoleArn := getString(config.Args, "awsRoleArn")
-		awsRegion := getString(config.Args, "awsRegion")
-
-		sess := session.Must(session.NewSession())
-		creds := sess.Config.Credentials
-
-		if awsRoleArn != "" {
-			creds = stscreds.NewCredentials(sess, awsRoleArn)
-		}
-
-		var region *string = nil
-		if awsRegion != "" {
-			region = &awsRegion
+		sess, awsConfig, err := newAwsSession(config)
+		if err != nil {
+			return nil, err
 		}
 
-		bucket := s3.New(sess, &aws.Config{Credentials: creds, Region: region})
+		bucket := s3.New(sess, awsConfig)
 		goi := s3.GetObjectInput{}
 		goi.Bucket = &u.Host
 		goi.Key = &u.Path
@@ -75,6 +71,12 @@ func readFile(config *SourceConfig) ([]byte, error) {
 		}
 
 		return data, nil
+	case "gs":
+		return readGcsFile(u)
+	case "azblob":
+		return readAzureBlob(config, u)
+	case "http", "https":
+		return readHttpFile(config, path)
 	case "":
 		return os.ReadFile(path)
 	default:
@@ -82,28 +84,64 @@ func readFile(config *SourceConfig) ([]byte, error) {
 	}
 }
 
-func convertFileConfig(config *SourceConfig) map[string]string {
-	data, err := readFile(config)
-	if err != nil {
-		panic(err)
+// fileFormat detects the format from the file extension, the query of remote URLs like SAS tokens is ignored.
+func fileFormat(path string) string {
+	if isRemotePath(path) {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
 	}
 
-	path := getString(config.Args, "path")
-	var raw map[string]interface{}
 	switch {
 	case strings.HasSuffix(path, ".yml"), strings.HasSuffix(path, ".yaml"):
-		if err := yaml.Unmarshal(data, &raw); err != nil {
-			panic(err)
-		}
+		return "yaml"
 	case strings.HasSuffix(path, ".json"):
-		if err := json.Unmarshal(data, &raw); err != nil {
-			panic(err)
-		}
+		return "json"
+	case strings.HasSuffix(path, ".env"):
+		return "dotenv"
+	case strings.HasSuffix(path, ".toml"):
+		return "toml"
+	case strings.HasSuffix(path, ".ini"):
+		return "ini"
+	case strings.HasSuffix(path, ".properties"):
+		return "properties"
+	case strings.HasSuffix(path, ".tfvars"):
+		return "tfvars"
 	default:
-		panic(errors.New("unsupported variable file type"))
+		return ""
+	}
+}
+
+func convertFileConfig(config *SourceConfig) (map[string]interface{}, error) {
+	data, err := readFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	path := getString(config.Args, "path")
+	format := getString(config.Args, "format")
+	if format == "" {
+		format = fileFormat(path)
+	}
+
+	sops, err := getBool(config.Args, "sops", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if sops || hasSopsMetadata(data, format) {
+		if data, err = decryptSops(data, format); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		config.secret = true
+	}
+
+	raw, err := parseFileFormat(data, format, path)
+	if err != nil {
+		return nil, err
 	}
 
-	flat := make(map[string]string)
+	flat := make(map[string]interface{})
 	flattenToMap(raw, "", flat)
-	return flat
+	return flat, nil
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FilterFunc transforms a resolved value, found is false if the key was not found from the source.
+type FilterFunc func(value string, found bool, args []string) (string, bool, error)
+
+// Filters is the registry of pipeline filters usable as ${key | filter arg...}.
+var Filters = map[string]FilterFunc{
+	"b64enc":    stringFilter(func(v string) string { return base64.StdEncoding.EncodeToString([]byte(v)) }),
+	"b64dec":    b64decFilter,
+	"quote":     stringFilter(strconv.Quote),
+	"squote":    stringFilter(func(v string) string { return "'" + strings.ReplaceAll(v, "'", "''") + "'" }),
+	"upper":     stringFilter(strings.ToUpper),
+	"lower":     stringFilter(strings.ToLower),
+	"trim":      stringFilter(strings.TrimSpace),
+	"sha256sum": stringFilter(func(v string) string { sum := sha256.Sum256([]byte(v)); return hex.EncodeToString(sum[:]) }),
+	"indent":    indentFilter(false),
+	"nindent":   indentFilter(true),
+	"default":   defaultFilter,
+	"required":  requiredFilter,
+}
+
+func stringFilter(f func(string) string) FilterFunc {
+	return func(value string, found bool, args []string) (string, bool, error) {
+		if !found {
+			return value, found, nil
+		}
+		return f(value), found, nil
+	}
+}
+
+func b64decFilter(value string, found bool, args []string) (string, bool, error) {
+	if !found {
+		return value, found, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return value, found, fmt.Errorf("b64dec: %w", err)
+	}
+
+	return string(decoded), found, nil
+}
+
+func indentFilter(newline bool) FilterFunc {
+	return func(value string, found bool, args []string) (string, bool, error) {
+		if len(args) != 1 {
+			return value, found, errors.New("indent requires a width")
+		}
+
+		width, err := strconv.Atoi(args[0])
+		if err != nil {
+			return value, found, fmt.Errorf("indent: invalid width %s", args[0])
+		}
+
+		if !found {
+			return value, found, nil
+		}
+
+		pad := strings.Repeat(" ", width)
+		value = pad + strings.ReplaceAll(value, "\n", "\n"+pad)
+		if newline {
+			value = "\n" + value
+		}
+
+		return value, found, nil
+	}
+}
+
+func defaultFilter(value string, found bool, args []string) (string, bool, error) {
+	if len(args) != 1 {
+		return value, found, errors.New("default requires a value")
+	}
+
+	if !found || value == "" {
+		return args[0], true, nil
+	}
+
+	return value, found, nil
+}
+
+func requiredFilter(value string, found bool, args []string) (string, bool, error) {
+	if !found || value == "" {
+		if len(args) > 0 {
+			return value, found, errors.New(strings.Join(args, " "))
+		}
+		return value, found, errors.New("required value is missing")
+	}
+
+	return value, found, nil
+}
+
+// splitPipeline splits a match key to the source key and the filter invocations following it.
+func splitPipeline(expr string) (string, [][]string, error) {
+	parts := strings.Split(expr, "|")
+	if len(parts) == 1 {
+		return expr, nil, nil
+	}
+
+	key := strings.TrimSpace(parts[0])
+
+	pipeline := [][]string{}
+	for _, part := range parts[1:] {
+		args, err := splitFilterArgs(part)
+		if err != nil {
+			return key, nil, err
+		}
+		if len(args) == 0 {
+			return key, nil, errors.New("empty filter in pipeline")
+		}
+		pipeline = append(pipeline, args)
+	}
+
+	return key, pipeline, nil
+}
+
+// splitFilterArgs splits a filter invocation on whitespace, double quoted strings are kept together.
+func splitFilterArgs(s string) ([]string, error) {
+	args := []string{}
+	s = strings.TrimSpace(s)
+
+	for len(s) > 0 {
+		if s[0] == '"' {
+			end := 1
+			for end < len(s) && (s[end] != '"' || s[end-1] == '\\') {
+				end++
+			}
+			if end == len(s) {
+				return nil, fmt.Errorf("unterminated string in filter: %s", s)
+			}
+
+			arg, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, arg)
+			s = s[end+1:]
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+
+			args = append(args, s[:end])
+			s = s[end:]
+		}
+
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+
+	return args, nil
+}
+
+// applyFilters runs the value through the filter pipeline.
+func applyFilters(value string, found bool, pipeline [][]string) (string, bool, error) {
+	for _, invocation := range pipeline {
+		filter, ok := Filters[invocation[0]]
+		if !ok {
+			return value, found, fmt.Errorf("unknown filter '%s'", invocation[0])
+		}
+
+		var err error
+		if value, found, err = filter(value, found, invocation[1:]); err != nil {
+			return value, found, err
+		}
+	}
+
+	return value, found, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitPipeline(t *testing.T) {
+	tests := []struct {
+		expr     string
+		key      string
+		pipeline [][]string
+		err      bool
+	}{
+		{expr: "key", key: "key"},
+		{expr: "key | upper", key: "key", pipeline: [][]string{{"upper"}}},
+		{expr: "key|b64enc|quote", key: "key", pipeline: [][]string{{"b64enc"}, {"quote"}}},
+		{expr: `key | default "a b" | indent 2`, key: "key", pipeline: [][]string{{"default", "a b"}, {"indent", "2"}}},
+		{expr: "key | ", err: true},
+		{expr: `key | default "open`, err: true},
+	}
+
+	for _, test := range tests {
+		key, pipeline, err := splitPipeline(test.expr)
+		if test.err {
+			if err == nil {
+				t.Errorf("splitPipeline(%q) expected an error", test.expr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("splitPipeline(%q) failed: %s", test.expr, err)
+			continue
+		}
+
+		if key != test.key || !reflect.DeepEqual(pipeline, test.pipeline) {
+			t.Errorf("splitPipeline(%q) = %q, %q, want %q, %q", test.expr, key, pipeline, test.key, test.pipeline)
+		}
+	}
+}
+
+func TestSplitFilterArgs(t *testing.T) {
+	tests := []struct {
+		s    string
+		args []string
+		err  bool
+	}{
+		{s: "", args: []string{}},
+		{s: "  upper  ", args: []string{"upper"}},
+		{s: "indent 4", args: []string{"indent", "4"}},
+		{s: `default ""`, args: []string{"default", ""}},
+		{s: `required "value is \"missing\""`, args: []string{"required", `value is "missing"`}},
+		{s: "default\tx\ny", args: []string{"default", "x", "y"}},
+		{s: `default "x`, err: true},
+	}
+
+	for _, test := range tests {
+		args, err := splitFilterArgs(test.s)
+		if test.err {
+			if err == nil {
+				t.Errorf("splitFilterArgs(%q) expected an error", test.s)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("splitFilterArgs(%q) failed: %s", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("splitFilterArgs(%q) = %q, want %q", test.s, args, test.args)
+		}
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	tests := []struct {
+		value    string
+		found    bool
+		pipeline [][]string
+		out      string
+		outFound bool
+		err      bool
+	}{
+		{value: "abc", found: true, pipeline: [][]string{{"upper"}}, out: "ABC", outFound: true},
+		{value: "abc", found: true, pipeline: [][]string{{"b64enc"}, {"b64dec"}}, out: "abc", outFound: true},
+		{value: "it's", found: true, pipeline: [][]string{{"squote"}}, out: "'it''s'", outFound: true},
+		{value: "a\nb", found: true, pipeline: [][]string{{"nindent", "2"}}, out: "\n  a\n  b", outFound: true},
+		{value: "abc", found: true, pipeline: [][]string{{"sha256sum"}}, out: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", outFound: true},
+		{found: false, pipeline: [][]string{{"upper"}}, out: "", outFound: false},
+		{found: false, pipeline: [][]string{{"default", "x"}, {"upper"}}, out: "X", outFound: true},
+		{value: "", found: true, pipeline: [][]string{{"default", "x"}}, out: "x", outFound: true},
+		{found: false, pipeline: [][]string{{"required"}}, err: true},
+		{value: "!", found: true, pipeline: [][]string{{"b64dec"}}, err: true},
+		{value: "x", found: true, pipeline: [][]string{{"indent"}}, err: true},
+		{value: "x", found: true, pipeline: [][]string{{"nope"}}, err: true},
+	}
+
+	for _, test := range tests {
+		out, found, err := applyFilters(test.value, test.found, test.pipeline)
+		if test.err {
+			if err == nil {
+				t.Errorf("applyFilters(%q, %q) expected an error", test.value, test.pipeline)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("applyFilters(%q, %q) failed: %s", test.value, test.pipeline, err)
+			continue
+		}
+
+		if out != test.out || found != test.outFound {
+			t.Errorf("applyFilters(%q, %q) = %q, %t, want %q, %t", test.value, test.pipeline, out, found, test.out, test.outFound)
+		}
+	}
+}
+
+func TestResolveFilters(t *testing.T) {
+	transform := Transform{
+		regex:   defaultTransformRegex,
+		sources: []map[string]interface{}{{"app.host": "example.com", "empty": ""}},
+	}
+
+	tests := []struct {
+		value string
+		out   string
+		err   bool
+	}{
+		{value: "${app.host | upper}", out: "EXAMPLE.COM"},
+		{value: "${app.port:8080}", out: "8080"},
+		{value: "${app.port:8080 | quote}", out: `"8080"`},
+		{value: `${app.port | default "a:b"}`, out: "a:b"},
+		{value: `${app.url | default "https://example.com:443/{path}"}`, out: "https://example.com:443/{path}"},
+		{value: `${app.scheme | default "say \"hi:there\""}`, out: `say "hi:there"`},
+		{value: `host=${app.host | default "x:y"}, port=${app.port | default "80"}`, out: "host=example.com, port=80"},
+		{value: "${app.port}", out: "${app.port}"},
+		{value: `${empty | required "msg: empty is required"}`, err: true},
+	}
+
+	for _, test := range tests {
+		out, err := transformInterface(test.value, []Transform{transform}, nil, []string{"data", "value"})
+		if test.err {
+			var resultErr *ResultError
+			if err == nil || !errors.As(err, &resultErr) || !strings.Contains(resultErr.Message, "msg: empty is required") {
+				t.Errorf("%s: expected the required error, got %v", test.value, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.value, err)
+			continue
+		}
+
+		if out != test.out {
+			t.Errorf("%s: got %q, want %q", test.value, out, test.out)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// parseFileFormat parses variable file data into a map, the format names are the ones returned by fileFormat.
+func parseFileFormat(data []byte, format string, path string) (map[string]interface{}, error) {
+	raw := make(map[string]interface{})
+
+	var err error
+	switch format {
+	case "yaml":
+		err = yaml.Unmarshal(data, &raw)
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	case "dotenv":
+		raw, err = parseDotenv(data)
+	case "toml":
+		if err = toml.Unmarshal(data, &raw); err == nil {
+			raw = tomlValue(raw).(map[string]interface{})
+		}
+	case "ini":
+		raw, err = parseIni(data)
+	case "properties":
+		raw, err = parseProperties(data)
+	case "tfvars":
+		raw, err = parseTfvars(data, path)
+	default:
+		return nil, errors.New("unsupported variable file type: " + path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return raw, nil
+}
+
+// tomlValue converts arrays of tables and datetimes decoded by the TOML parser to plain lists, maps and
+// RFC 3339 strings that can be flattened.
+func tomlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = tomlValue(e)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = tomlValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = tomlValue(e)
+		}
+		return out
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// unquoteDotenv returns a quoted value and its remaining lines, double quoted values support escapes and
+// both kinds of quotes can span multiple lines.
+func unquoteDotenv(value string, lines []string) (string, []string, error) {
+	quote := value[0]
+	value = value[1:]
+
+	var out strings.Builder
+	for {
+		for i := 0; i < len(value); i++ {
+			c := value[i]
+
+			if c == quote {
+				return out.String(), lines, nil
+			}
+
+			if c == '\\' && quote == '"' && i+1 < len(value) {
+				i++
+				switch value[i] {
+				case 'n':
+					out.WriteByte('\n')
+				case 'r':
+					out.WriteByte('\r')
+				case 't':
+					out.WriteByte('\t')
+				default:
+					out.WriteByte(value[i])
+				}
+				continue
+			}
+
+			out.WriteByte(c)
+		}
+
+		if len(lines) == 0 {
+			return "", nil, errors.New("unterminated quoted value")
+		}
+
+		out.WriteByte('\n')
+		value, lines = lines[0], lines[1:]
+	}
+}
+
+// parseDotenv parses KEY=value lines with optional export prefixes, quotes and comments.
+func parseDotenv(data []byte) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for len(lines) > 0 {
+		line := strings.TrimSpace(lines[0])
+		lines = lines[1:]
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		key := strings.TrimSpace(k)
+		value := strings.TrimSpace(v)
+
+		if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+			var err error
+			if value, lines, err = unquoteDotenv(value, lines); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		} else if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+
+		out[key] = value
+	}
+
+	return out, nil
+}
+
+// parseIni parses an INI file, keys of sections are prefixed with the section name.
+func parseIni(data []byte) (map[string]interface{}, error) {
+	file, err := ini.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+	for _, section := range file.Sections() {
+		values := out
+		if section.Name() != ini.DefaultSection {
+			values = make(map[string]interface{})
+			out[section.Name()] = values
+		}
+
+		for _, key := range section.Keys() {
+			values[key.Name()] = key.Value()
+		}
+	}
+
+	return out, nil
+}
+
+// unescapeProperties resolves the backslash escapes of Java properties.
+func unescapeProperties(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			out.WriteByte('\t')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 'f':
+			out.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					out.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			out.WriteByte('u')
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+
+	return out.String()
+}
+
+// parseProperties parses Java properties, dotted keys are kept as they are already flat.
+func parseProperties(data []byte) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	var logical string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+
+		if logical == "" && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// an odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, `\`))
+		if trailing%2 == 1 {
+			logical += line[:len(line)-1]
+			continue
+		}
+
+		logical += line
+		line, logical = logical, ""
+
+		setProperty(out, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// a continuation on the last line ends the file
+	if logical != "" {
+		setProperty(out, logical)
+	}
+
+	return out, nil
+}
+
+// setProperty stores a logical properties line, the key ends at the first unescaped separator.
+func setProperty(out map[string]interface{}, line string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	key := line[:end]
+	value := strings.TrimLeft(line[end:], " \t\f")
+	if len(value) > 0 && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+
+	out[unescapeProperties(key)] = unescapeProperties(value)
+}
+
+// parseTfvars evaluates the attributes of a Terraform variable definitions file.
+func parseTfvars(data []byte, path string) (map[string]interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attributes, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	out := make(map[string]interface{})
+	for name, attribute := range attributes {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		encoded, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		var decoded interface{}
+		decoder := json.NewDecoder(bytes.NewReader(encoded))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		out[name] = jsonValue(decoded)
+	}
+
+	return out, nil
+}
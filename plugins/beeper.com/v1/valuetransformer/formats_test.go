@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		data string
+		out  map[string]interface{}
+		err  bool
+	}{
+		{data: "A=1\nB = two\n", out: map[string]interface{}{"A": "1", "B": "two"}},
+		{data: "# comment\n\nexport A=1\nnot a pair\n", out: map[string]interface{}{"A": "1"}},
+		{data: "A=value # comment\nB=a#b\n", out: map[string]interface{}{"A": "value", "B": "a#b"}},
+		{data: `A="a\nb \"c\""` + "\nB='a\\nb'\n", out: map[string]interface{}{"A": "a\nb \"c\"", "B": `a\nb`}},
+		{data: "A=\"line one\nline two\"\nB=2\n", out: map[string]interface{}{"A": "line one\nline two", "B": "2"}},
+		{data: "A=\"# not a comment\"\n", out: map[string]interface{}{"A": "# not a comment"}},
+		{data: "A=\nB=''\n", out: map[string]interface{}{"A": "", "B": ""}},
+		{data: "A=\"open\nB=2\n", err: true},
+	}
+
+	for _, test := range tests {
+		out, err := parseDotenv([]byte(test.data))
+		if test.err {
+			if err == nil {
+				t.Errorf("parseDotenv(%q) expected an error, got %v", test.data, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseDotenv(%q) failed: %s", test.data, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("parseDotenv(%q) = %q, want %q", test.data, out, test.out)
+		}
+	}
+}
+
+func TestParseProperties(t *testing.T) {
+	tests := []struct {
+		data string
+		out  map[string]interface{}
+	}{
+		{data: "a=1\nb:2\nc 3\n", out: map[string]interface{}{"a": "1", "b": "2", "c": "3"}},
+		{data: "# comment\n! comment\n\n  a.b.c = x\n", out: map[string]interface{}{"a.b.c": "x"}},
+		{data: "a=one \\\n    two\nb=3\n", out: map[string]interface{}{"a": "one two", "b": "3"}},
+		{data: "a=1\nb=two \\", out: map[string]interface{}{"a": "1", "b": "two "}},
+		{data: "a=1\nb=two \\\n", out: map[string]interface{}{"a": "1", "b": "two "}},
+		{data: "path=c:\\\\dir\n", out: map[string]interface{}{"path": `c:\dir`}},
+		{data: "a\\=b=c\nkey\\ with\\ spaces=v\n", out: map[string]interface{}{"a=b": "c", "key with spaces": "v"}},
+		{data: "tab=a\\tb\nu=\\u00e9\n", out: map[string]interface{}{"tab": "a\tb", "u": "é"}},
+		{data: "empty\n", out: map[string]interface{}{"empty": ""}},
+	}
+
+	for _, test := range tests {
+		out, err := parseProperties([]byte(test.data))
+		if err != nil {
+			t.Errorf("parseProperties(%q) failed: %s", test.data, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("parseProperties(%q) = %q, want %q", test.data, out, test.out)
+		}
+	}
+}
+
+func TestParseFileFormatFlatten(t *testing.T) {
+	raw, err := parseFileFormat([]byte("[[servers]]\nname = \"a\"\nstarted = 2024-01-02T03:04:05Z\n"), "toml", "test.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := make(map[string]interface{})
+	flattenToMap(raw, "", out)
+
+	if out["servers.0.name"] != "a" || out["servers.0.started"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("got %#v", out)
+	}
+}
+
+func TestParseFileFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+		out    map[string]interface{}
+		err    bool
+	}{
+		{format: "yaml", data: "a: 1\nb: {c: x}\n", out: map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": "x"}}},
+		{format: "json", data: `{"a": "x"}`, out: map[string]interface{}{"a": "x"}},
+		{format: "toml", data: "a = 1\n[b]\nc = \"x\"\n", out: map[string]interface{}{"a": int64(1), "b": map[string]interface{}{"c": "x"}}},
+		{format: "toml", data: "[[servers]]\nname = \"a\"\n[[servers]]\nname = \"b\"\nports = [80, 443]\n", out: map[string]interface{}{"servers": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b", "ports": []interface{}{int64(80), int64(443)}}}}},
+		{format: "toml", data: "at = 1979-05-27T07:32:00Z\nday = 1979-05-27\n", out: map[string]interface{}{"at": "1979-05-27T07:32:00Z", "day": "1979-05-27T00:00:00Z"}},
+		{format: "ini", data: "a = 1\n[b]\nc = x\n", out: map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": "x"}}},
+		{format: "tfvars", data: "a = 1\nb = [\"x\", 2.5]\nc = { d = true }\n", out: map[string]interface{}{"a": int64(1), "b": []interface{}{"x", 2.5}, "c": map[string]interface{}{"d": true}}},
+		{format: "tfvars", data: "a = var.b\n", err: true},
+		{format: "toml", data: "a = \n", err: true},
+		{format: "xml", data: "<a/>", err: true},
+	}
+
+	for _, test := range tests {
+		out, err := parseFileFormat([]byte(test.data), test.format, "test."+test.format)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseFileFormat(%q, %s) expected an error, got %v", test.data, test.format, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseFileFormat(%q, %s) failed: %s", test.data, test.format, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("parseFileFormat(%q, %s) = %#v, want %#v", test.data, test.format, out, test.out)
+		}
+	}
+}
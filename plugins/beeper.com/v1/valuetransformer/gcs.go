@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// readGcsFile reads a gs:// object with application default credentials, STORAGE_EMULATOR_HOST is honored.
+func readGcsFile(u *url.URL) ([]byte, error) {
+	ctx := context.Background()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	reader, err := client.Bucket(u.Host).Object(strings.TrimPrefix(u.Path, "/")).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
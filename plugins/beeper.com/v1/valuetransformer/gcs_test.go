@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestGcs serves objects like a GCS emulator, both the XML and JSON API download paths are handled.
+func newTestGcs(t *testing.T, objects map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/download/storage/v1/b/")
+		path = strings.Replace(path, "/o/", "/", 1)
+		path = strings.TrimPrefix(path, "/")
+
+		object, ok := objects[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(object))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestConvertFileConfigGcs(t *testing.T) {
+	server := newTestGcs(t, map[string]string{"bucket/vars/app.yaml": "foo: bar\n"})
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	out, err := convertFileConfig(&SourceConfig{Type: "File", Args: map[string]interface{}{"path": "gs://bucket/vars/app.yaml"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, map[string]interface{}{"foo": "bar"}) {
+		t.Errorf("got %#v", out)
+	}
+
+	if _, err := convertFileConfig(&SourceConfig{Type: "File", Args: map[string]interface{}{"path": "gs://bucket/missing.yaml"}}); err == nil {
+		t.Errorf("expected an error for a missing object")
+	}
+}
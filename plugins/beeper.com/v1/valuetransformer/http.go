@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultHttpTimeout = 30 * time.Second
+
+type etagEntry struct {
+	ETag string `yaml:"etag"`
+	Body []byte `yaml:"body"`
+}
+
+func newHttpClient(config *SourceConfig) (*http.Client, error) {
+	timeout := defaultHttpTimeout
+	if t := getString(config.Args, "timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	if caFile := getString(config.Args, "caFile"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + caFile)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// newHttpRequest builds a GET request with the configured headers and authentication.
+func newHttpRequest(config *SourceConfig, path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := config.Args["headers"]; ok {
+		for k, v := range getMap(config.Args, "headers") {
+			req.Header.Set(k, toString(v))
+		}
+	}
+
+	token := getString(config.Args, "bearerToken")
+	if env := getString(config.Args, "bearerTokenEnv"); env != "" {
+		var ok bool
+		if token, ok = os.LookupEnv(env); !ok {
+			return nil, fmt.Errorf("bearer token environment variable %s is not set", env)
+		}
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if username := getString(config.Args, "username"); username != "" {
+		req.SetBasicAuth(username, getString(config.Args, "password"))
+	}
+
+	return req, nil
+}
+
+// readHttpFile fetches a file over HTTP(S), responses with an ETag are revalidated from the cache when enabled.
+func readHttpFile(config *SourceConfig, path string) ([]byte, error) {
+	client, err := newHttpClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newHttpRequest(config, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var etagKey string
+	var cached etagEntry
+	if config.cache != nil && config.cache.allowsBody(config) {
+		if etagKey, err = config.cache.Key(config); err != nil {
+			return nil, err
+		}
+		etagKey = "etag-" + etagKey
+
+		if plain, ok := config.cache.read(etagKey, 0); ok && yaml.Unmarshal(plain, &cached) == nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if cached.ETag != "" {
+			if DebugEnabled {
+				fmt.Fprintf(os.Stderr, "Using cached %s, ETag %s not modified\n", path, cached.ETag)
+			}
+			return cached.Body, nil
+		}
+		fallthrough
+	default:
+		return nil, fmt.Errorf("failed to fetch %s: %s", path, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && etagKey != "" {
+		plain, err := yaml.Marshal(etagEntry{ETag: etag, Body: data})
+		if err == nil {
+			err = config.cache.write(etagKey, plain)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache %s: %s\n", path, err)
+		}
+	}
+
+	return data, nil
+}
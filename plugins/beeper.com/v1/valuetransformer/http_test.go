@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// newTestEtagServer serves a body with an ETag and counts the requests answered with 304 Not Modified.
+func newTestEtagServer(t *testing.T, body string, notModified *int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestCache returns a cache in a temporary directory with the internal key, entries expire immediately.
+func newTestCache(t *testing.T) *SourceCache {
+	t.Helper()
+
+	t.Setenv("VALUETRANSFORMER_CACHE", "")
+	t.Setenv("VALUETRANSFORMER_CACHE_KEY", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	cache, err := newSourceCache(&CacheConfig{Dir: t.TempDir(), Ttl: "1ns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cache
+}
+
+func cacheEntries(t *testing.T, cache *SourceCache) int {
+	t.Helper()
+
+	entries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(entries)
+}
+
+func TestReadHttpFileEtag(t *testing.T) {
+	disabled := false
+	state := `{"version": 4, "outputs": {"password": {"value": "hunter2", "type": "string", "sensitive": true}}}`
+
+	tests := []struct {
+		name   string
+		body   string
+		source func(url string) SourceConfig
+		cached bool
+	}{
+		{
+			name: "file",
+			body: "foo: bar\n",
+			source: func(url string) SourceConfig {
+				return SourceConfig{Type: "File", Args: map[string]interface{}{"path": url + "/vars.yaml"}}
+			},
+			cached: true,
+		},
+		{
+			name: "cache disabled",
+			body: "foo: bar\n",
+			source: func(url string) SourceConfig {
+				return SourceConfig{Type: "File", Args: map[string]interface{}{"path": url + "/vars.yaml"}, Cache: &disabled}
+			},
+		},
+		{
+			name: "sensitive state over http backend",
+			body: state,
+			source: func(url string) SourceConfig {
+				return SourceConfig{Type: "TerraformState", Args: map[string]interface{}{"backend": "http", "address": url + "/state"}}
+			},
+		},
+		{
+			name: "sensitive state over https path",
+			body: state,
+			source: func(url string) SourceConfig {
+				return SourceConfig{Type: "TerraformState", Args: map[string]interface{}{"path": url + "/terraform.tfstate"}}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		notModified := 0
+		server := newTestEtagServer(t, test.body, &notModified)
+		cache := newTestCache(t)
+
+		for i := 0; i < 2; i++ {
+			if _, err := loadSource(test.name, test.source(server.URL), cache); err != nil {
+				t.Fatalf("%s: failed: %s", test.name, err)
+			}
+		}
+
+		entries := cacheEntries(t, cache)
+		if test.cached && (entries == 0 || notModified != 1) {
+			t.Errorf("%s: expected the response to be cached and revalidated, got %d entries and %d revalidations", test.name, entries, notModified)
+		} else if !test.cached && (entries != 0 || notModified != 0) {
+			t.Errorf("%s: expected nothing cached, got %d entries and %d revalidations", test.name, entries, notModified)
+		}
+	}
+}
+
+func TestReadHttpFileEtagExternalKey(t *testing.T) {
+	notModified := 0
+	server := newTestEtagServer(t, `{"version": 4, "outputs": {"password": {"value": "hunter2", "type": "string", "sensitive": true}}}`, &notModified)
+	cache := newTestCache(t)
+	cache.external = true
+
+	source := SourceConfig{Type: "TerraformState", Args: map[string]interface{}{"backend": "http", "address": server.URL + "/state"}}
+	for i := 0; i < 2; i++ {
+		vars, err := loadSource("state", source, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if vars["password"] != (Sensitive{"hunter2"}) {
+			t.Errorf("got %#v, want sensitive hunter2", vars["password"])
+		}
+	}
+
+	if notModified != 1 {
+		t.Errorf("expected the state to be revalidated with an external key, got %d revalidations", notModified)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeChain formats the files that lead to an include for error messages.
+func includeChain(chain []string) string {
+	if len(chain) == 0 {
+		return "configuration"
+	}
+	return strings.Join(chain, " -> ")
+}
+
+// resolveIncludes merges included files recursively, relative paths are resolved against the including file.
+// Includes of an inline configuration without a path are relative to the working directory.
+func resolveIncludes(config *TransformerConfig, configPath string) error {
+	included := map[string]struct{}{}
+
+	if configPath == "" {
+		return includeFiles(config, config.Includes, "", nil, included, 1)
+	}
+
+	if abs, err := filepath.Abs(configPath); err == nil {
+		configPath = abs
+	}
+	included[configPath] = struct{}{}
+
+	return includeFiles(config, config.Includes, configPath, []string{configPath}, included, 1)
+}
+
+// includeLocations expands an include to the files it refers to, remote parents resolve relative paths as URLs.
+func includeLocations(include string, parent string) ([]string, error) {
+	if isRemotePath(include) {
+		return []string{include}, nil
+	}
+
+	if isRemotePath(parent) {
+		base, err := url.Parse(parent)
+		if err != nil {
+			return nil, err
+		}
+
+		ref, err := url.Parse(include)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{base.ResolveReference(ref).String()}, nil
+	}
+
+	pattern := include
+	if !filepath.IsAbs(pattern) && parent != "" {
+		pattern = filepath.Join(filepath.Dir(parent), pattern)
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// plain paths must exist, globs may match nothing
+	if len(files) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, &os.PathError{Op: "include", Path: pattern, Err: os.ErrNotExist}
+	}
+
+	for i, file := range files {
+		if abs, err := filepath.Abs(file); err == nil {
+			files[i] = abs
+		}
+	}
+
+	return files, nil
+}
+
+// readInclude fetches an included file through the File source schemes and verifies its pinned checksum.
+func readInclude(location string, include *Include) ([]byte, error) {
+	args := map[string]interface{}{}
+	for k, v := range include.Args {
+		args[k] = expandEnvInterface(v)
+	}
+	args["path"] = location
+
+	data, err := readFile(&SourceConfig{Type: "File", Args: args})
+	if err != nil {
+		return nil, err
+	}
+
+	if include.Sha256 != "" {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, include.Sha256) {
+			return nil, fmt.Errorf("sha256 mismatch, expected %s but got %s", include.Sha256, actual)
+		}
+	}
+
+	return data, nil
+}
+
+func includeFiles(config *TransformerConfig, includes []Include, parent string, chain []string, included map[string]struct{}, depth int) error {
+	for i := range includes {
+		include := &includes[i]
+		path := expandEnvInterface(include.Path).(string)
+
+		locations, err := includeLocations(path, parent)
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) && errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("included file %s not found, included from %s", pathErr.Path, includeChain(chain))
+		} else if err != nil {
+			return fmt.Errorf("invalid include '%s': %w, included from %s", include.Path, err, includeChain(chain))
+		}
+
+		for _, location := range locations {
+			// no re-including files that we already have
+			if _, ok := included[location]; ok {
+				continue
+			}
+
+			included[location] = struct{}{}
+			fileChain := append(append([]string{}, chain...), location)
+
+			if DebugEnabled {
+				fmt.Fprintf(os.Stderr, "Including file: %s\n", includeChain(fileChain))
+			}
+
+			data, err := readInclude(location, include)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("included file %s not found, included from %s", location, includeChain(chain))
+				}
+				return fmt.Errorf("failed to include %s: %w", includeChain(fileChain), err)
+			}
+
+			includeConfig := TransformerConfig{}
+			if err := yaml.Unmarshal(data, &includeConfig); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", includeChain(fileChain), err)
+			}
+
+			nested := includeConfig.Includes
+			includeConfig.Includes = nil
+
+			if err := mergeConfig(config, &includeConfig, depth); err != nil {
+				return fmt.Errorf("%w, included from %s", err, includeChain(fileChain))
+			}
+
+			if err := includeFiles(config, nested, location, fileChain, included, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeTestFiles writes the files relative to a temporary directory and returns it.
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// testSource is a Variable source with a single value a, optionally overriding.
+func testSource(value string, override bool) string {
+	if override {
+		return "sources:\n  vars:\n    type: Variable\n    override: true\n    vars:\n      a: " + value + "\n"
+	}
+	return "sources:\n  vars:\n    type: Variable\n    vars:\n      a: " + value + "\n"
+}
+
+func TestResolveIncludesOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		value string
+		err   string
+	}{
+		{
+			name: "root overrides include",
+			files: map[string]string{
+				"root.yaml":    "includes: [base/vt.yaml]\n" + testSource("root", true),
+				"base/vt.yaml": testSource("base", false),
+			},
+			value: "root",
+		},
+		{
+			name: "root without override",
+			files: map[string]string{
+				"root.yaml":    "includes: [base/vt.yaml]\n" + testSource("root", false),
+				"base/vt.yaml": testSource("base", false),
+			},
+			err: "duplicate source: vars",
+		},
+		{
+			name: "include can not override root",
+			files: map[string]string{
+				"root.yaml":    "includes: [base/vt.yaml]\n" + testSource("root", false),
+				"base/vt.yaml": testSource("base", true),
+			},
+			err: "can not override source vars of the root config",
+		},
+		{
+			name: "include can not override root override",
+			files: map[string]string{
+				"root.yaml":    "includes: [base/vt.yaml]\n" + testSource("root", true),
+				"base/vt.yaml": testSource("base", true),
+			},
+			value: "root",
+		},
+		{
+			name: "layered overrides",
+			files: map[string]string{
+				"root.yaml":           "includes: [overlay/vt.yaml]\n",
+				"overlay/vt.yaml":     "includes: [../base/vt.yaml]\n" + testSource("overlay", true),
+				"base/vt.yaml":        "includes: [common/vt.yaml]\n" + testSource("base", true),
+				"base/common/vt.yaml": testSource("common", false),
+			},
+			value: "overlay",
+		},
+		{
+			name: "base overrides common",
+			files: map[string]string{
+				"root.yaml":           "includes: [base/vt.yaml]\n",
+				"base/vt.yaml":        "includes: [common/vt.yaml]\n" + testSource("base", true),
+				"base/common/vt.yaml": testSource("common", false),
+			},
+			value: "base",
+		},
+		{
+			name: "deeper include can not override closer file",
+			files: map[string]string{
+				"root.yaml":           "includes: [base/vt.yaml]\n",
+				"base/vt.yaml":        "includes: [common/vt.yaml]\n" + testSource("base", false),
+				"base/common/vt.yaml": testSource("common", true),
+			},
+			err: "can not override source vars of a file closer to the root config",
+		},
+		{
+			name: "closer include merged later overrides deeper file",
+			files: map[string]string{
+				"root.yaml": "includes: [a/vt.yaml, b.yaml]\n",
+				"a/vt.yaml": "includes: [c.yaml]\n",
+				"a/c.yaml":  testSource("c", false),
+				"b.yaml":    testSource("b", true),
+			},
+			value: "b",
+		},
+		{
+			name: "later sibling overrides",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml, b.yaml]\n",
+				"a.yaml":    testSource("a", false),
+				"b.yaml":    testSource("b", true),
+			},
+			value: "b",
+		},
+		{
+			name: "earlier sibling overrides",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml, b.yaml]\n",
+				"a.yaml":    testSource("a", true),
+				"b.yaml":    testSource("b", false),
+			},
+			value: "a",
+		},
+		{
+			name: "sibling overrides conflict",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml, b.yaml]\n",
+				"a.yaml":    testSource("a", true),
+				"b.yaml":    testSource("b", true),
+			},
+			err: "conflicting override for source: vars",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"root.yaml": "includes: [missing.yaml]\n",
+			},
+			err: "missing.yaml not found",
+		},
+	}
+
+	for _, test := range tests {
+		dir := writeTestFiles(t, test.files)
+		root := filepath.Join(dir, "root.yaml")
+
+		data, err := os.ReadFile(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config := TransformerConfig{}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		err = resolveIncludes(&config, root)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected error containing '%s', got %v", test.name, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		if value := config.Sources["vars"].Vars["a"]; value != test.value {
+			t.Errorf("%s: got %v, want %s", test.name, value, test.value)
+		}
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	dst := TransformerConfig{
+		Sources:    map[string]SourceConfig{"root": {Type: "Variable"}},
+		Merges:     map[string]interface{}{"m": "root"},
+		Transforms: []TransformConfig{{Source: "root"}},
+		Cache:      CacheConfig{Ttl: "1h"},
+	}
+
+	src := TransformerConfig{
+		Sources:    map[string]SourceConfig{"include": {Type: "Variable"}},
+		Transforms: []TransformConfig{{Source: "include"}},
+		Excludes:   []Selector{{Kind: "Secret"}},
+		Cache:      CacheConfig{Dir: "/tmp/cache", Ttl: "1m"},
+	}
+
+	if err := mergeConfig(&dst, &src, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := dst.Sources["include"]; !ok || len(dst.Sources) != 2 {
+		t.Errorf("sources not merged: %v", dst.Sources)
+	}
+
+	if len(dst.Transforms) != 2 || dst.Transforms[1].Source != "include" || len(dst.Excludes) != 1 {
+		t.Errorf("transforms or excludes not appended: %v %v", dst.Transforms, dst.Excludes)
+	}
+
+	if dst.Cache.Dir != "/tmp/cache" || dst.Cache.Ttl != "1h" {
+		t.Errorf("cache should only be filled in from includes: %v", dst.Cache)
+	}
+
+	if err := mergeConfig(&dst, &TransformerConfig{Merges: map[string]interface{}{"m": "include"}}, 1); err == nil {
+		t.Errorf("expected an error for a duplicate merge")
+	}
+}
+
+func TestResolveIncludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		sources []string
+		err     string
+	}{
+		{
+			name: "relative to including file",
+			files: map[string]string{
+				"root.yaml":        "includes: [base/vt.yaml]\n",
+				"base/vt.yaml":     "includes: [common.yaml]\ntransforms: [{source: base}]\n",
+				"base/common.yaml": "transforms: [{source: common}]\n",
+			},
+			sources: []string{"base", "common"},
+		},
+		{
+			name: "glob",
+			files: map[string]string{
+				"root.yaml":     "includes: ['common/*.yaml']\ntransforms: [{source: root}]\n",
+				"common/b.yaml": "transforms: [{source: b}]\n",
+				"common/a.yaml": "transforms: [{source: a}]\n",
+				"common/c.txt":  "transforms: [{source: c}]\n",
+			},
+			sources: []string{"root", "a", "b"},
+		},
+		{
+			name: "glob without matches",
+			files: map[string]string{
+				"root.yaml": "includes: ['common/*.yaml']\n",
+			},
+			sources: []string{},
+		},
+		{
+			name: "included files are skipped",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml, b.yaml]\n",
+				"a.yaml":    "includes: [c.yaml, root.yaml]\ntransforms: [{source: a}]\n",
+				"b.yaml":    "includes: [c.yaml, a.yaml]\ntransforms: [{source: b}]\n",
+				"c.yaml":    "transforms: [{source: c}]\n",
+			},
+			sources: []string{"a", "c", "b"},
+		},
+		{
+			name: "missing nested include",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml]\n",
+				"a.yaml":    "includes: [missing.yaml]\n",
+			},
+			err: "DIR/missing.yaml not found, included from DIR/root.yaml -> DIR/a.yaml",
+		},
+		{
+			name: "sha256 mismatch",
+			files: map[string]string{
+				"root.yaml": "includes: [{path: a.yaml, sha256: '0000'}]\n",
+				"a.yaml":    "transforms: [{source: a}]\n",
+			},
+			err: "failed to include DIR/root.yaml -> DIR/a.yaml: sha256 mismatch",
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				"root.yaml": "includes: [a.yaml]\n",
+				"a.yaml":    "transforms: {source: a}\n",
+			},
+			err: "failed to parse DIR/root.yaml -> DIR/a.yaml",
+		},
+	}
+
+	for _, test := range tests {
+		dir := writeTestFiles(t, test.files)
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		root := filepath.Join(dir, "root.yaml")
+
+		data, err := os.ReadFile(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config := TransformerConfig{}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		err = resolveIncludes(&config, root)
+		if test.err != "" {
+			expected := strings.ReplaceAll(test.err, "DIR", dir)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("%s: expected error containing '%s', got %v", test.name, expected, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		sources := []string{}
+		for _, transform := range config.Transforms {
+			sources = append(sources, transform.Source)
+		}
+
+		if !reflect.DeepEqual(sources, test.sources) {
+			t.Errorf("%s: got transforms %q, want %q", test.name, sources, test.sources)
+		}
+	}
+}
+
+func TestIncludeLocations(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"base/vt.yaml": "", "base/common.yaml": ""})
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	tests := []struct {
+		include   string
+		parent    string
+		locations []string
+		err       bool
+	}{
+		{include: "https://example.com/vt.yaml", parent: filepath.Join(dir, "root.yaml"), locations: []string{"https://example.com/vt.yaml"}},
+		{include: "common/vt.yaml", parent: "https://example.com/base/vt.yaml", locations: []string{"https://example.com/base/common/vt.yaml"}},
+		{include: "../vt.yaml", parent: "gs://bucket/base/vt.yaml", locations: []string{"gs://bucket/vt.yaml"}},
+		{include: "common.yaml", parent: filepath.Join(dir, "base", "vt.yaml"), locations: []string{filepath.Join(dir, "base", "common.yaml")}},
+		{include: "base/*.yaml", parent: filepath.Join(dir, "root.yaml"), locations: []string{filepath.Join(dir, "base", "common.yaml"), filepath.Join(dir, "base", "vt.yaml")}},
+		{include: filepath.Join(dir, "base", "vt.yaml"), parent: "/elsewhere/root.yaml", locations: []string{filepath.Join(dir, "base", "vt.yaml")}},
+		{include: "other/*.yaml", parent: filepath.Join(dir, "root.yaml"), locations: nil},
+		{include: "missing.yaml", parent: filepath.Join(dir, "root.yaml"), err: true},
+		{include: "base/[", parent: filepath.Join(dir, "root.yaml"), err: true},
+	}
+
+	for _, test := range tests {
+		locations, err := includeLocations(test.include, test.parent)
+		if test.err {
+			if err == nil {
+				t.Errorf("includeLocations(%q, %q) expected an error, got %q", test.include, test.parent, locations)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("includeLocations(%q, %q) failed: %s", test.include, test.parent, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(locations, test.locations) {
+			t.Errorf("includeLocations(%q, %q) = %q, want %q", test.include, test.parent, locations, test.locations)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// newKubernetesClientConfig loads the kubeconfig like kubectl does, args select the file and context.
+func newKubernetesClientConfig(config *SourceConfig) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig := getString(config.Args, "kubeconfig"); kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeContext := getString(config.Args, "context"); kubeContext != "" {
+		overrides.CurrentContext = kubeContext
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
+
+// kubernetesContextIdentity describes the resolved context, cluster and user of a source.
+func kubernetesContextIdentity(config *SourceConfig) string {
+	clientConfig := newKubernetesClientConfig(config)
+
+	raw, err := clientConfig.RawConfig()
+	if err != nil {
+		return ""
+	}
+
+	contextName := raw.CurrentContext
+	if kubeContext := getString(config.Args, "context"); kubeContext != "" {
+		contextName = kubeContext
+	}
+
+	kubeContext, ok := raw.Contexts[contextName]
+	if !ok {
+		return contextName
+	}
+
+	server := ""
+	if cluster, ok := raw.Clusters[kubeContext.Cluster]; ok {
+		server = cluster.Server
+	}
+
+	return strings.Join([]string{contextName, server, kubeContext.AuthInfo, kubeContext.Namespace}, "/")
+}
+
+// kubernetesObjectField walks a parsed field path through an object.
+func kubernetesObjectField(object interface{}, segments []string) (interface{}, bool) {
+	for _, segment := range segments {
+		if isIndexSegment(segment) {
+			list, ok := object.([]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			i, err := strconv.Atoi(segment[1 : len(segment)-1])
+			if err != nil || i < 0 || i >= len(list) {
+				return nil, false
+			}
+			object = list[i]
+		} else {
+			m, ok := object.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			if object, ok = m[segment]; !ok {
+				return nil, false
+			}
+		}
+	}
+
+	return object, true
+}
+
+// kubernetesObjectData returns the decoded data of Secrets and ConfigMaps.
+func kubernetesObjectData(kind string, object map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	var plain, encoded []string
+	switch kind {
+	case "Secret":
+		plain, encoded = []string{"stringData"}, []string{"data"}
+	case "ConfigMap":
+		plain, encoded = []string{"data"}, []string{"binaryData"}
+	}
+
+	for _, field := range plain {
+		if data, ok := object[field].(map[string]interface{}); ok {
+			for k, v := range data {
+				out[k] = v
+			}
+		}
+	}
+
+	for _, field := range encoded {
+		data, ok := object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for k, v := range data {
+			decoded, err := base64.StdEncoding.DecodeString(toString(v))
+			if err != nil {
+				return nil, fmt.Errorf("%s key '%s' is not valid base64: %w", field, k, err)
+			}
+			out[k] = string(decoded)
+		}
+	}
+
+	return out, nil
+}
+
+func convertKubernetesObjectConfig(config *SourceConfig) (map[string]interface{}, error) {
+	kind := getString(config.Args, "kind")
+	name := getString(config.Args, "name")
+	if kind == "" || name == "" {
+		return nil, errors.New("kind and name are required for KubernetesObject source")
+	}
+
+	apiVersion := getString(config.Args, "apiVersion")
+	if apiVersion == "" {
+		apiVersion = "v1"
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := newKubernetesClientConfig(config)
+
+	namespace := getString(config.Args, "namespace")
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	mapping, err := mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := client.Resource(mapping.Resource)
+	var getter dynamic.ResourceInterface = resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		getter = resource.Namespace(namespace)
+	}
+
+	object, err := getter.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s in %s: %w", kind, name, namespace, err)
+	}
+
+	var value interface{}
+	if path := getString(config.Args, "fieldPath"); path != "" {
+		segments, err := parseFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var found bool
+		if value, found = kubernetesObjectField(object.Object, segments); !found {
+			return nil, fmt.Errorf("field '%s' not found in %s/%s", path, kind, name)
+		}
+
+		// a scalar field is exposed under the last segment of its path
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			value = map[string]interface{}{strings.Trim(segments[len(segments)-1], "[]"): value}
+		}
+	} else if kind == "Secret" || kind == "ConfigMap" {
+		if value, err = kubernetesObjectData(kind, object.Object); err != nil {
+			return nil, fmt.Errorf("%s/%s %w", kind, name, err)
+		}
+	} else {
+		value = object.Object
+	}
+
+	out := make(map[string]interface{})
+	flattenToMap(value, "", out)
+	return out, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestKubernetes serves core v1 discovery and a few objects like the API server.
+func newTestKubernetes(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	responses := map[string]interface{}{
+		"/api":  map[string]interface{}{"kind": "APIVersions", "versions": []string{"v1"}},
+		"/apis": map[string]interface{}{"kind": "APIGroupList", "apiVersion": "v1", "groups": []interface{}{}},
+		"/api/v1": map[string]interface{}{"kind": "APIResourceList", "groupVersion": "v1", "resources": []interface{}{
+			map[string]interface{}{"name": "secrets", "namespaced": true, "kind": "Secret", "verbs": []string{"get"}},
+			map[string]interface{}{"name": "configmaps", "namespaced": true, "kind": "ConfigMap", "verbs": []string{"get"}},
+			map[string]interface{}{"name": "services", "namespaced": true, "kind": "Service", "verbs": []string{"get"}},
+			map[string]interface{}{"name": "namespaces", "namespaced": false, "kind": "Namespace", "verbs": []string{"get"}},
+		}},
+		"/api/v1/namespaces/prod/secrets/db": map[string]interface{}{
+			"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "db"},
+			"data":       map[string]interface{}{"password": b64("hunter2")},
+			"stringData": map[string]interface{}{"user": "app"},
+		},
+		"/api/v1/namespaces/default/configmaps/cfg": map[string]interface{}{
+			"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "cfg"},
+			"data":       map[string]interface{}{"a": "b"},
+			"binaryData": map[string]interface{}{"bin": b64("BIN")},
+		},
+		"/api/v1/namespaces/default/services/lb": map[string]interface{}{
+			"apiVersion": "v1", "kind": "Service", "metadata": map[string]interface{}{"name": "lb"},
+			"status": map[string]interface{}{"loadBalancer": map[string]interface{}{"ingress": []interface{}{map[string]interface{}{"hostname": "lb.example.com"}}}},
+		},
+		"/api/v1/namespaces/prod": map[string]interface{}{
+			"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "prod", "labels": map[string]interface{}{"env": "production"}},
+		},
+	}
+
+	// bearer tokens are only sent over TLS
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "Unauthorized", "code": 401})
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404})
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// writeTestKubeconfig writes a kubeconfig with a default context and a prod context using the prod namespace.
+func writeTestKubeconfig(t *testing.T, server string, ca []byte) string {
+	t.Helper()
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: ` + server + `
+    certificate-authority-data: ` + base64.StdEncoding.EncodeToString(ca) + `
+users:
+- name: user
+  user:
+    token: token
+- name: other
+  user:
+    token: other
+contexts:
+- name: test
+  context: {cluster: test, user: user}
+- name: prod
+  context: {cluster: test, user: user, namespace: prod}
+- name: denied
+  context: {cluster: test, user: other}
+current-context: test
+`
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestConvertKubernetesObjectConfig(t *testing.T) {
+	server := newTestKubernetes(t)
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfig := writeTestKubeconfig(t, server.URL, ca)
+
+	tests := []struct {
+		args map[string]interface{}
+		out  map[string]interface{}
+		err  bool
+	}{
+		{
+			args: map[string]interface{}{"kind": "Secret", "name": "db", "namespace": "prod"},
+			out:  map[string]interface{}{"password": "hunter2", "user": "app"},
+		},
+		{
+			args: map[string]interface{}{"kind": "Secret", "name": "db", "context": "prod"},
+			out:  map[string]interface{}{"password": "hunter2", "user": "app"},
+		},
+		{
+			args: map[string]interface{}{"kind": "ConfigMap", "name": "cfg"},
+			out:  map[string]interface{}{"a": "b", "bin": "BIN"},
+		},
+		{
+			args: map[string]interface{}{"kind": "Service", "name": "lb", "fieldPath": "status.loadBalancer.ingress[0].hostname"},
+			out:  map[string]interface{}{"hostname": "lb.example.com"},
+		},
+		{
+			args: map[string]interface{}{"kind": "Service", "name": "lb", "fieldPath": "status.loadBalancer.ingress[0]"},
+			out:  map[string]interface{}{"hostname": "lb.example.com"},
+		},
+		{
+			args: map[string]interface{}{"kind": "Namespace", "name": "prod", "fieldPath": "metadata.labels"},
+			out:  map[string]interface{}{"env": "production"},
+		},
+		{
+			args: map[string]interface{}{"kind": "Service", "name": "lb", "fieldPath": "spec.clusterIP"},
+			err:  true,
+		},
+		{
+			args: map[string]interface{}{"kind": "Secret", "name": "missing"},
+			err:  true,
+		},
+		{
+			args: map[string]interface{}{"kind": "Secret", "name": "db", "namespace": "prod", "context": "denied"},
+			err:  true,
+		},
+		{
+			args: map[string]interface{}{"kind": "Widget", "name": "db"},
+			err:  true,
+		},
+		{
+			args: map[string]interface{}{"kind": "Secret"},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test.args["kubeconfig"] = kubeconfig
+		out, err := convertKubernetesObjectConfig(&SourceConfig{Type: "KubernetesObject", Args: test.args})
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %v", test.args, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: failed: %s", test.args, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("%v: got %#v, want %#v", test.args, out, test.out)
+		}
+	}
+}
+
+func TestKubernetesContextIdentity(t *testing.T) {
+	kubeconfig := writeTestKubeconfig(t, "https://cluster.example.com", nil)
+
+	tests := []struct {
+		context  string
+		identity string
+	}{
+		{context: "", identity: "test/https://cluster.example.com/user/"},
+		{context: "prod", identity: "prod/https://cluster.example.com/user/prod"},
+		{context: "missing", identity: "missing"},
+	}
+
+	for _, test := range tests {
+		config := SourceConfig{Type: "KubernetesObject", Args: map[string]interface{}{"kubeconfig": kubeconfig, "context": test.context}}
+		if identity := kubernetesContextIdentity(&config); identity != test.identity {
+			t.Errorf("context '%s': got %s, want %s", test.context, identity, test.identity)
+		}
+	}
+}
@@ -12,154 +12,153 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func convertEnvironmentConfig(config *SourceConfig, filter map[string]string) map[string]string {
-	out := make(map[string]string)
+func convertEnvironmentConfig(config *SourceConfig, filter map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
 
 	for k, v := range filter {
 		if env, ok := os.LookupEnv(k); ok {
-			out[v] = env
+			out[toString(v)] = env
 		}
 	}
 
 	return out
 }
 
-func resolveIncludes(config *TransformerConfig) {
-	includes := map[string]struct{}{}
-	nincludes := -1
+func convertSource(source *SourceConfig) (map[string]interface{}, error) {
+	switch source.Type {
+	case "File":
+		return convertFileConfig(source)
+	case "Exec":
+		return convertExecConfig(source)
+	case "SecretsManager":
+		return convertSecretsManagerConfig(source)
+	case "ParameterStore":
+		return convertParameterStoreConfig(source)
+	case "Vault":
+		return convertVaultConfig(source)
+	case "TerraformState":
+		return convertTerraformStateConfig(source)
+	case "KubernetesObject":
+		return convertKubernetesObjectConfig(source)
+	default:
+		return nil, errors.New("invalid source type " + source.Type)
+	}
+}
 
-	// include loop is going to be run until we don't include any new files anymore
-	for len(includes) > nincludes {
-		nincludes = len(includes)
+func loadSource(name string, source SourceConfig, cache *SourceCache) (map[string]interface{}, error) {
+	// expand into a copy as the config is written back to the output
+	args := make(map[string]interface{}, len(source.Args))
+	for k, v := range source.Args {
+		args[k] = expandEnvInterface(v)
+	}
+	source.Args = args
 
-		for _, includeFile := range config.Includes {
-			includeFile = expandEnvInterface(includeFile).(string)
+	flatVars := make(map[string]interface{})
+	flattenToMapWithJsonify(source.Vars, "", flatVars, false)
 
-			// no re-including files that we already have
-			if _, ok := includes[includeFile]; ok {
-				continue
-			}
+	switch source.Type {
+	case "Variable":
+		return flatVars, nil
+	case "Environment":
+		return convertEnvironmentConfig(&source, flatVars), nil
+	}
+
+	cached := cache != nil && isCachedSource(&source)
+	if cached && !cache.allows(&source, nil) {
+		if DebugEnabled {
+			fmt.Fprintf(os.Stderr, "Source '%s' has secrets and is not cached without VALUETRANSFORMER_CACHE_KEY\n", name)
+		}
+		cached = false
+	}
 
-			includes[includeFile] = struct{}{}
+	var cacheKey string
+	if cached {
+		var err error
+		if cacheKey, err = cache.Key(&source); err != nil {
+			return nil, err
+		}
 
+		if vars, ok := cache.Get(cacheKey); ok {
 			if DebugEnabled {
-				fmt.Fprintf(os.Stderr, "Including file: %s\n", includeFile)
+				fmt.Fprintf(os.Stderr, "Source '%s' loaded from cache\n", name)
 			}
-
-			includeConfig := TransformerConfig{}
-			readYamlFile(includeFile, &includeConfig)
-			mergeConfig(config, &includeConfig)
+			return filterMap(vars, flatVars), nil
 		}
 	}
-}
 
-var DebugEnabled bool
-var mergeSplit *regexp.Regexp = regexp.MustCompile(`^([^\.]+)\.(.+)$`)
-
-func main() {
-	envDebug := strings.ToUpper(os.Getenv("VALUETRANSFORMER_DEBUG"))
-	if len(envDebug) > 0 && (envDebug[0] == '1' || envDebug[0] == 'T') {
-		DebugEnabled = true
-		fmt.Fprintf(os.Stderr, "- WARNING - ValueTransformer debugging enabled - WARNING -\n")
+	if cached {
+		source.cache = cache
+	}
+	vars, err := convertSource(&source)
+	if err != nil {
+		return nil, err
 	}
 
-	rl := &ResourceList{}
-
-	legacy := false
-	stdinDecoder := yaml.NewDecoder(os.Stdin)
-
-	// check if we are called as a legacy alpha plugin
-	if len(os.Args) > 1 {
-		readYamlFile(os.Args[1], &rl.FunctionConfig)
-
-		for {
-			item := make(map[string]interface{})
-			if err := stdinDecoder.Decode(&item); err != nil {
-				if err == io.EOF {
-					break
-				}
-				panic(err)
-			}
-
-			rl.Items = append(rl.Items, item)
+	if cacheKey != "" && !cache.allows(&source, vars) {
+		if DebugEnabled {
+			fmt.Fprintf(os.Stderr, "Source '%s' has secrets and is not cached without VALUETRANSFORMER_CACHE_KEY\n", name)
 		}
-
-		// enable legacy output
-		legacy = true
-	} else {
-		if err := stdinDecoder.Decode(rl); err != nil {
-			panic(err)
+	} else if cacheKey != "" {
+		if err := cache.Put(cacheKey, vars); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache source '%s': %s\n", name, err)
 		}
 	}
 
-	resolveIncludes(&rl.FunctionConfig)
-
-	if rl.FunctionConfig.Kind != "ValueTransformer" {
-		panic(errors.New("unsupported Kind, expected ValueTransformer"))
-	}
+	return filterMap(vars, flatVars), nil
+}
 
-	if rl.FunctionConfig.ApiVersion != "beeper.com/v1" {
-		panic(errors.New("unsupported apiVersion, expected beeper.com/v1"))
+func loadSources(config *TransformerConfig) (map[string]map[string]interface{}, error) {
+	cache, err := newSourceCache(&config.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	// initialize all sources
 	var wg sync.WaitGroup
-	sources := make(map[string]map[string]string)
+	var sourceErr error
+	sources := make(map[string]map[string]interface{})
 	var sourceLock sync.Mutex
-	for name, source := range rl.FunctionConfig.Sources {
+
+	for name, source := range config.Sources {
 		wg.Add(1)
 
 		go func(name string, source SourceConfig) {
-			for k, v := range source.Args {
-				source.Args[k] = expandEnvInterface(v)
-			}
+			defer wg.Done()
+
+			vars, err := loadSource(name, source, cache)
+
+			sourceLock.Lock()
+			defer sourceLock.Unlock()
 
-			flatVars := make(map[string]string)
-			flattenToMapWithJsonify(source.Vars, "", flatVars, false)
-
-			var vars map[string]string
-
-			switch source.Type {
-			case "Variable":
-				vars = flatVars
-			case "Environment":
-				vars = convertEnvironmentConfig(&source, flatVars)
-			case "File":
-				vars = filterMap(convertFileConfig(&source), flatVars)
-			case "Exec":
-				vars = filterMap(convertExecConfig(&source), flatVars)
-			case "SecretsManager":
-				vars = filterMap(convertSecretsManagerConfig(&source), flatVars)
-			case "TerraformState":
-				vars = filterMap(convertTerraformStateConfig(&source), flatVars)
-			default:
-				panic(errors.New("Invalid source type " + source.Type))
+			if err != nil {
+				if sourceErr == nil {
+					sourceErr = fmt.Errorf("source '%s': %w", name, err)
+				}
+				return
 			}
 
 			if DebugEnabled {
 				fmt.Fprintf(os.Stderr, "Source '%s':\n", name)
 				for k, v := range vars {
-					fmt.Fprintf(os.Stderr, "\t%s (%d chars)\n", k, len(v))
+					fmt.Fprintf(os.Stderr, "\t%s (%s)\n", k, debugLength(v))
 				}
 			}
 
-			defer sourceLock.Unlock()
-			sourceLock.Lock()
 			sources[name] = vars
-
-			wg.Done()
 		}(name, source)
 	}
 
 	wg.Wait()
 
-	// apply source merges
-	for name, merge := range rl.FunctionConfig.Merges {
+	return sources, sourceErr
+}
+
+func resolveMerges(config *TransformerConfig, sources map[string]map[string]interface{}) error {
+	for name, merge := range config.Merges {
 		if _, found := sources[name]; found {
-			panic(fmt.Errorf("merge '%s' is already a source", name))
+			return fmt.Errorf("merge '%s' is already a source", name)
 		}
 
-		flatMerge := make(map[string]string)
+		flatMerge := make(map[string]interface{})
 		flattenToMapWithJsonify(merge, "", flatMerge, false)
 
 		if DebugEnabled {
@@ -167,9 +166,9 @@ func main() {
 		}
 
 		for k, v := range flatMerge {
-			split := mergeSplit.FindStringSubmatch(os.ExpandEnv(v))
+			split := mergeSplit.FindStringSubmatch(os.ExpandEnv(toString(v)))
 			if len(split) < 3 {
-				panic(fmt.Errorf("merge value '%s' was not a reference to a source", v))
+				return fmt.Errorf("merge value '%s' was not a reference to a source", v)
 			}
 
 			sourceName := split[1]
@@ -179,39 +178,165 @@ func main() {
 				if value, ok := source[sourceKey]; ok {
 					flatMerge[k] = value
 				} else {
-					panic(fmt.Errorf("merge key '%s' was not found from source '%s'", sourceKey, sourceName))
+					return fmt.Errorf("merge key '%s' was not found from source '%s'", sourceKey, sourceName)
 				}
 			} else {
-				panic(fmt.Errorf("merge source '%s' was not found for key '%s'", sourceName, sourceKey))
+				return fmt.Errorf("merge source '%s' was not found for key '%s'", sourceName, sourceKey)
 			}
 
 			if DebugEnabled {
-				fmt.Fprintf(os.Stderr, "\t%s (%d chars)\n", k, len(flatMerge[k]))
+				fmt.Fprintf(os.Stderr, "\t%s (%s)\n", k, debugLength(flatMerge[k]))
 			}
 		}
 
 		sources[name] = flatMerge
 	}
 
-	newItems := make([]map[string]interface{}, len(rl.Items))
-	for i, item := range rl.Items {
-		newItems[i] = applyTransforms(item, &rl.FunctionConfig, sources)
+	return nil
+}
+
+// readInput decodes the resource list from stdin, returns true if called as a legacy alpha plugin.
+func readInput(rl *ResourceList) (bool, error) {
+	stdinDecoder := yaml.NewDecoder(os.Stdin)
+
+	if len(os.Args) <= 1 {
+		if err := stdinDecoder.Decode(rl); err != nil {
+			return false, fmt.Errorf("failed to parse ResourceList: %w", err)
+		}
+		return false, nil
+	}
+
+	if err := readYamlFile(os.Args[1], &rl.FunctionConfig); err != nil {
+		return true, err
+	}
+
+	for {
+		item := make(map[string]interface{})
+		if err := stdinDecoder.Decode(&item); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return true, fmt.Errorf("failed to parse resource: %w", err)
+		}
+
+		rl.Items = append(rl.Items, item)
+	}
+
+	return true, nil
+}
+
+func writeOutput(rl *ResourceList, legacy bool) error {
+	for _, result := range rl.Results {
+		severity := result.Severity
+		if severity != "" {
+			severity = strings.ToUpper(severity[:1]) + severity[1:]
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", severity, result)
+	}
+
+	// legacy output is a plain stream of items which may be empty
+	if legacy && len(rl.Items) == 0 {
+		return nil
 	}
-	rl.Items = newItems
 
 	encoder := yaml.NewEncoder(os.Stdout)
 	if legacy {
 		for _, item := range rl.Items {
 			if err := encoder.Encode(item); err != nil {
-				panic(err)
+				return err
 			}
 		}
 	} else {
 		if err := encoder.Encode(rl); err != nil {
-			panic(err)
+			return err
 		}
 	}
-	if err := encoder.Close(); err != nil {
-		panic(err)
+
+	return encoder.Close()
+}
+
+func run(rl *ResourceList, configPath string) error {
+	if err := resolveIncludes(&rl.FunctionConfig, configPath); err != nil {
+		return err
+	}
+
+	if rl.FunctionConfig.Kind != "ValueTransformer" {
+		return errors.New("unsupported Kind, expected ValueTransformer")
+	}
+
+	if rl.FunctionConfig.ApiVersion != "beeper.com/v1" {
+		return errors.New("unsupported apiVersion, expected beeper.com/v1")
+	}
+
+	// initialize all sources
+	sources, err := loadSources(&rl.FunctionConfig)
+	if err != nil {
+		return err
+	}
+
+	// apply source merges
+	if err := resolveMerges(&rl.FunctionConfig, sources); err != nil {
+		return err
+	}
+
+	newItems := make([]map[string]interface{}, len(rl.Items))
+	for i, item := range rl.Items {
+		newItem, results, err := applyTransforms(item, &rl.FunctionConfig, sources)
+		if err != nil {
+			return err
+		}
+
+		newItems[i] = newItem
+		rl.Results = append(rl.Results, results...)
+	}
+	rl.Items = newItems
+
+	unresolved := 0
+	for _, result := range rl.Results {
+		if result.Severity == SeverityError {
+			unresolved++
+		}
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("strict mode: %d unresolved matches", unresolved)
+	}
+
+	return nil
+}
+
+var DebugEnabled bool
+var mergeSplit *regexp.Regexp = regexp.MustCompile(`^([^\.]+)\.(.+)$`)
+
+func main() {
+	envDebug := strings.ToUpper(os.Getenv("VALUETRANSFORMER_DEBUG"))
+	if len(envDebug) > 0 && (envDebug[0] == '1' || envDebug[0] == 'T') {
+		DebugEnabled = true
+		fmt.Fprintf(os.Stderr, "- WARNING - ValueTransformer debugging enabled - WARNING -\n")
+	}
+
+	rl := &ResourceList{}
+
+	legacy, err := readInput(rl)
+	if err == nil {
+		configPath := ""
+		if legacy {
+			configPath = os.Args[1]
+		}
+
+		err = run(rl, configPath)
+	}
+
+	if err != nil {
+		rl.Results = append(rl.Results, errorResult(err))
+	}
+
+	if err := writeOutput(rl, legacy); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		os.Exit(1)
 	}
 }
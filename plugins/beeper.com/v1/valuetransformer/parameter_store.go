@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// parameterKey converts an SSM parameter name relative to prefix into dot notation.
+func parameterKey(name string, prefix string) string {
+	name = strings.TrimPrefix(name, strings.TrimSuffix(prefix, "/"))
+	return strings.ReplaceAll(strings.Trim(name, "/"), "/", ".")
+}
+
+func convertParameterStoreConfig(config *SourceConfig) (map[string]interface{}, error) {
+	name := getString(config.Args, "name")
+	prefix := getString(config.Args, "path")
+	if len(name) == 0 && len(prefix) == 0 {
+		return nil, errors.New("no parameter name or path given")
+	}
+
+	decrypt, err := getBool(config.Args, "withDecryption", true)
+	if err != nil {
+		return nil, err
+	}
+
+	recursive, err := getBool(config.Args, "recursive", true)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, awsConfig, err := newAwsSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := ssm.New(sess, awsConfig)
+	out := make(map[string]interface{})
+
+	if len(name) > 0 {
+		gpi := ssm.GetParameterInput{}
+		gpi.Name = &name
+		gpi.WithDecryption = &decrypt
+
+		gpo, err := ps.GetParameter(&gpi)
+		if err != nil {
+			return nil, err
+		}
+
+		out[path.Base(*gpo.Parameter.Name)] = aws.StringValue(gpo.Parameter.Value)
+		return out, nil
+	}
+
+	gpi := ssm.GetParametersByPathInput{}
+	gpi.Path = &prefix
+	gpi.Recursive = &recursive
+	gpi.WithDecryption = &decrypt
+
+	err = ps.GetParametersByPathPages(&gpi, func(page *ssm.GetParametersByPathOutput, lastPage bool) bool {
+		for _, p := range page.Parameters {
+			out[parameterKey(aws.StringValue(p.Name), prefix)] = aws.StringValue(p.Value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResultError is an error that carries a KRM function result with resource context.
+type ResultError struct {
+	Result
+}
+
+func (e *ResultError) Error() string {
+	return e.Result.String()
+}
+
+// errorResult converts any error to an error severity result.
+func errorResult(err error) Result {
+	var resultErr *ResultError
+	if errors.As(err, &resultErr) {
+		return resultErr.Result
+	}
+
+	return Result{Message: err.Error(), Severity: SeverityError}
+}
+
+func (r Result) String() string {
+	var b strings.Builder
+
+	b.WriteString(r.Message)
+
+	if r.ResourceRef != nil {
+		fmt.Fprintf(&b, " for resource %s/%s", r.ResourceRef.Kind, r.ResourceRef.Name)
+		if r.ResourceRef.Namespace != "" {
+			fmt.Fprintf(&b, " in namespace %s", r.ResourceRef.Namespace)
+		}
+	}
+
+	if r.Field != nil && r.Field.Path != "" {
+		fmt.Fprintf(&b, " at %s", r.Field.Path)
+	}
+
+	return b.String()
+}
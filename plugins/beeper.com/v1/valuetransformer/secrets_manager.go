@@ -3,49 +3,116 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
 
-func convertSecretsManagerConfig(config *SourceConfig) map[string]string {
-	name := getString(config.Args, "name")
-	if len(name) == 0 {
-		panic(errors.New("no secret name given"))
+func getSecretValue(sm *secretsmanager.SecretsManager, config *SourceConfig, name string) (interface{}, error) {
+	svi := secretsmanager.GetSecretValueInput{}
+	svi.SecretId = &name
+
+	if versionId := getString(config.Args, "versionId"); versionId != "" {
+		svi.VersionId = &versionId
+	}
+	if versionStage := getString(config.Args, "versionStage"); versionStage != "" {
+		svi.VersionStage = &versionStage
 	}
 
-	awsRoleArn := getString(config.Args, "awsRoleArn")
-	awsRegion := getString(config.Args, "awsRegion")
+	svo, err := sm.GetSecretValue(&svi)
+	if err != nil {
+		return nil, err
+	}
 
-	sess := session.Must(session.NewSession())
-	creds := sess.Config.Credentials
+	// non-JSON and binary secrets are exposed under a single key
+	plaintextKey := getString(config.Args, "plaintextKey")
+	if plaintextKey == "" {
+		plaintextKey = "value"
+	}
 
-	if awsRoleArn != "" {
-		creds = stscreds.NewCredentials(sess, awsRoleArn)
+	if svo.SecretString == nil {
+		return map[string]interface{}{plaintextKey: string(svo.SecretBinary)}, nil
 	}
 
-	var region *string = nil
-	if awsRegion != "" {
-		region = &awsRegion
+	raw := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(*svo.SecretString), &raw); err != nil {
+		return map[string]interface{}{plaintextKey: *svo.SecretString}, nil
 	}
 
-	sm := secretsmanager.New(sess, &aws.Config{Credentials: creds, Region: region})
+	return raw, nil
+}
 
-	svi := secretsmanager.GetSecretValueInput{}
-	svi.SecretId = &name
-	svo, err := sm.GetSecretValue(&svi)
+func listSecretNames(sm *secretsmanager.SecretsManager, prefix string) ([]string, error) {
+	names := []string{}
+
+	lsi := secretsmanager.ListSecretsInput{}
+	lsi.Filters = []*secretsmanager.Filter{{
+		Key:    aws.String(secretsmanager.FilterNameStringTypeName),
+		Values: []*string{&prefix},
+	}}
+
+	err := sm.ListSecretsPages(&lsi, func(page *secretsmanager.ListSecretsOutput, lastPage bool) bool {
+		for _, s := range page.SecretList {
+			// the name filter matches prefixes of any word in the name, keep only real prefixes
+			if strings.HasPrefix(aws.StringValue(s.Name), prefix) {
+				names = append(names, aws.StringValue(s.Name))
+			}
+		}
+		return true
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	return names, nil
+}
+
+func convertSecretsManagerConfig(config *SourceConfig) (map[string]interface{}, error) {
+	name := getString(config.Args, "name")
+	names := getStringList(config.Args, "names")
+	namePrefix := getString(config.Args, "namePrefix")
+	if len(name) == 0 && len(names) == 0 && len(namePrefix) == 0 {
+		return nil, errors.New("no secret name, names or namePrefix given")
+	}
+
+	sess, awsConfig, err := newAwsSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sm := secretsmanager.New(sess, awsConfig)
+
+	out := make(map[string]interface{})
+
+	if len(name) > 0 {
+		raw, err := getSecretValue(sm, config, name)
+		if err != nil {
+			return nil, err
+		}
+
+		flattenToMap(raw, "", out)
+		return out, nil
+	}
+
+	if len(namePrefix) > 0 {
+		prefixed, err := listSecretNames(sm, namePrefix)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, prefixed...)
+	}
+
+	// multiple secrets are rooted at their name relative to the prefix
 	raw := make(map[string]interface{})
-	if err := json.Unmarshal([]byte(*svo.SecretString), &raw); err != nil {
-		panic(err)
+	for _, n := range names {
+		value, err := getSecretValue(sm, config, n)
+		if err != nil {
+			return nil, err
+		}
+		raw[parameterKey(n, namePrefix)] = value
 	}
 
-	out := make(map[string]string)
 	flattenToMap(raw, "", out)
-	return out
+	return out, nil
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/minio/pkg/wildcard"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const regexPatternPrefix = "regex:"
+
+var patternCache = map[string]*regexp.Regexp{}
+var patternLock sync.Mutex
+
+// resourceMeta holds the identifying fields of a resource for selector matching.
+type resourceMeta struct {
+	apiVersion  string
+	kind        string
+	name        string
+	namespace   string
+	labels      map[string]string
+	annotations map[string]string
+}
+
+func getStringMap(r map[string]interface{}, key string) map[string]string {
+	out := make(map[string]string)
+
+	switch m := r[key].(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			out[k] = toString(v)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			if ks, ok := k.(string); ok {
+				out[ks] = toString(v)
+			}
+		}
+	}
+
+	return out
+}
+
+func newResourceMeta(resource map[string]interface{}) *resourceMeta {
+	metadata := getMap(resource, "metadata")
+
+	return &resourceMeta{
+		apiVersion:  getString(resource, "apiVersion"),
+		kind:        getString(resource, "kind"),
+		name:        getString(metadata, "name"),
+		namespace:   getString(metadata, "namespace"),
+		labels:      getStringMap(metadata, "labels"),
+		annotations: getStringMap(metadata, "annotations"),
+	}
+}
+
+// splitApiVersion splits apiVersion to group and version, the core group is empty.
+func splitApiVersion(apiVersion string) (string, string) {
+	if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+		return group, version
+	}
+	return "", apiVersion
+}
+
+// matchPattern matches a value against an exact, glob or regex: prefixed pattern, empty pattern matches anything.
+func matchPattern(pattern string, value string) (bool, error) {
+	switch {
+	case pattern == "":
+		return true, nil
+	case strings.HasPrefix(pattern, regexPatternPrefix):
+		patternLock.Lock()
+		defer patternLock.Unlock()
+
+		regex, ok := patternCache[pattern]
+		if !ok {
+			var err error
+			if regex, err = regexp.Compile("^(?:" + strings.TrimPrefix(pattern, regexPatternPrefix) + ")$"); err != nil {
+				return false, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+			}
+			patternCache[pattern] = regex
+		}
+
+		return regex.MatchString(value), nil
+	case strings.ContainsAny(pattern, "*?"):
+		return wildcard.Match(pattern, value), nil
+	default:
+		return pattern == value, nil
+	}
+}
+
+// targets returns all target selectors, a transform without targets matches everything.
+func (t *TransformConfig) targets() []Selector {
+	if len(t.Targets) == 0 {
+		return []Selector{t.Target}
+	}
+
+	if t.Target != (Selector{}) {
+		return append([]Selector{t.Target}, t.Targets...)
+	}
+
+	return t.Targets
+}
+
+// matchTarget returns the index of the first target matching the resource.
+func (t *TransformConfig) matchTarget(res *resourceMeta) (int, bool, error) {
+	for i, target := range t.targets() {
+		if match, err := target.matches(res); err != nil {
+			return i, false, err
+		} else if match {
+			return i, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (s *Selector) matches(res *resourceMeta) (bool, error) {
+	group, version := splitApiVersion(res.apiVersion)
+
+	if s.Group != "" && s.Group != group {
+		return false, nil
+	}
+	if s.Version != "" && s.Version != version {
+		return false, nil
+	}
+
+	for _, field := range []struct{ pattern, value string }{
+		{s.Kind, res.kind},
+		{s.Name, res.name},
+		{s.Namespace, res.namespace},
+	} {
+		if match, err := matchPattern(field.pattern, field.value); err != nil || !match {
+			return false, err
+		}
+	}
+
+	if s.LabelSelector != "" {
+		selector, err := labels.Parse(s.LabelSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid labelSelector '%s': %w", s.LabelSelector, err)
+		}
+		if !selector.Matches(labels.Set(res.labels)) {
+			return false, nil
+		}
+	}
+
+	if s.AnnotationSelector != "" {
+		requirements, err := parseAnnotationSelector(s.AnnotationSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid annotationSelector '%s': %w", s.AnnotationSelector, err)
+		}
+		for _, requirement := range requirements {
+			if !requirement.matches(res.annotations) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// annotationRequirement is a label selector requirement without the label value syntax, annotation values are free form.
+type annotationRequirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+var annotationSetRequirement = regexp.MustCompile(`^([^\s=!(),]+)\s+(in|notin)\s*\((.*)\)$`)
+
+// parseAnnotationSelector parses comma separated key, !key, key=value, key!=value, key in (...) and key notin (...)
+// requirements, values can contain anything but commas.
+func parseAnnotationSelector(selector string) ([]annotationRequirement, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, selector[start:])
+
+	requirements := make([]annotationRequirement, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+
+		var requirement annotationRequirement
+		if set := annotationSetRequirement.FindStringSubmatch(part); set != nil {
+			requirement = annotationRequirement{key: set[1], operator: set[2]}
+			for _, value := range strings.Split(set[3], ",") {
+				requirement.values = append(requirement.values, strings.TrimSpace(value))
+			}
+		} else if strings.HasPrefix(part, "!") {
+			requirement = annotationRequirement{key: strings.TrimSpace(part[1:]), operator: "!"}
+		} else if i := strings.Index(part, "="); i >= 0 {
+			if i > 0 && part[i-1] == '!' {
+				requirement = annotationRequirement{key: part[:i-1], operator: "!="}
+			} else {
+				requirement = annotationRequirement{key: part[:i], operator: "="}
+			}
+			requirement.values = []string{strings.TrimSpace(strings.TrimPrefix(part[i+1:], "="))}
+		} else {
+			requirement = annotationRequirement{key: part, operator: "exists"}
+		}
+
+		requirement.key = strings.TrimSpace(requirement.key)
+		if requirement.key == "" || strings.ContainsAny(requirement.key, " \t()") {
+			return nil, fmt.Errorf("invalid requirement '%s'", part)
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, nil
+}
+
+func (r *annotationRequirement) matches(annotations map[string]string) bool {
+	value, ok := annotations[r.key]
+
+	contains := false
+	for _, v := range r.values {
+		if v == value {
+			contains = true
+			break
+		}
+	}
+
+	switch r.operator {
+	case "exists":
+		return ok
+	case "!":
+		return !ok
+	case "=", "in":
+		return ok && contains
+	default:
+		// != and notin also match when the annotation is missing
+		return !ok || !contains
+	}
+}
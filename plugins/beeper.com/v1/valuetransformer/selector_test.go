@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		match   bool
+		err     bool
+	}{
+		{pattern: "", value: "anything", match: true},
+		{pattern: "app", value: "app", match: true},
+		{pattern: "app", value: "app-config", match: false},
+		{pattern: "app-*", value: "app-config", match: true},
+		{pattern: "app-*", value: "other-config", match: false},
+		{pattern: "app-?", value: "app-1", match: true},
+		{pattern: "app-?", value: "app-12", match: false},
+		{pattern: "regex:app-[0-9]+", value: "app-12", match: true},
+		{pattern: "regex:app-[0-9]+", value: "my-app-12", match: false},
+		{pattern: "regex:a|b", value: "b", match: true},
+		{pattern: "regex:a|b", value: "ab", match: false},
+		{pattern: "regex:(", value: "x", err: true},
+	}
+
+	for _, test := range tests {
+		match, err := matchPattern(test.pattern, test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("matchPattern(%q, %q) expected an error", test.pattern, test.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("matchPattern(%q, %q) failed: %s", test.pattern, test.value, err)
+			continue
+		}
+
+		if match != test.match {
+			t.Errorf("matchPattern(%q, %q) = %t, want %t", test.pattern, test.value, match, test.match)
+		}
+	}
+}
+
+func TestSelectorAnnotationSelector(t *testing.T) {
+	annotations := map[string]string{
+		"example.com/repo": "https://github.com/example/app",
+		"description":      "the main app: frontend",
+		"valuetransformer": "enabled",
+		"team":             "payments",
+	}
+
+	tests := []struct {
+		selector string
+		match    bool
+		err      bool
+	}{
+		{selector: "example.com/repo=https://github.com/example/app", match: true},
+		{selector: "example.com/repo == https://github.com/example/app", match: true},
+		{selector: "example.com/repo=https://github.com/example/other", match: false},
+		{selector: "description=the main app: frontend", match: true},
+		{selector: "description!=the main app: frontend", match: false},
+		{selector: "missing!=x", match: true},
+		{selector: "team in (payments, billing)", match: true},
+		{selector: "team notin (payments, billing)", match: false},
+		{selector: "missing notin (a)", match: true},
+		{selector: "missing in (a)", match: false},
+		{selector: "team, valuetransformer=enabled", match: true},
+		{selector: "team, !valuetransformer", match: false},
+		{selector: "!missing", match: true},
+		{selector: "missing", match: false},
+		{selector: "team,", err: true},
+		{selector: "=value", err: true},
+	}
+
+	for _, test := range tests {
+		selector := Selector{AnnotationSelector: test.selector}
+		match, err := selector.matches(&resourceMeta{annotations: annotations})
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.selector)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.selector, err)
+			continue
+		}
+
+		if match != test.match {
+			t.Errorf("%s: got %t, want %t", test.selector, match, test.match)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+const sensitiveTag = "!sensitive"
+
+// Sensitive marks a source value that is redacted from debug output.
+type Sensitive struct {
+	Value interface{}
+}
+
+// MarshalYAML tags the value so cached sources keep their sensitivity.
+func (s Sensitive) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(s.Value); err != nil {
+		return nil, err
+	}
+
+	node.Tag = sensitiveTag
+	return node, nil
+}
+
+// decodeSensitiveNode decodes a value, wrapping it if it was tagged as sensitive.
+func decodeSensitiveNode(node *yaml.Node) (interface{}, error) {
+	sensitive := node.Tag == sensitiveTag
+	if sensitive {
+		node.Tag = ""
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if sensitive {
+		return Sensitive{value}, nil
+	}
+	return value, nil
+}
+
+// unwrapSensitive returns the plain value and whether it was sensitive.
+func unwrapSensitive(i interface{}) (interface{}, bool) {
+	if s, ok := i.(Sensitive); ok {
+		return s.Value, true
+	}
+	return i, false
+}
+
+// debugLength describes a value for debug output without revealing sensitive values.
+func debugLength(i interface{}) string {
+	if _, ok := i.(Sensitive); ok {
+		return "sensitive"
+	}
+	return fmt.Sprintf("%d chars", len(toString(i)))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/getsops/sops/v3/decrypt"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+type sopsDocument struct {
+	Sops map[string]interface{} `yaml:"sops" json:"sops"`
+}
+
+// hasSopsMetadata checks if the file looks like it was encrypted by sops.
+func hasSopsMetadata(data []byte, format string) bool {
+	doc := sopsDocument{}
+
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return false
+		}
+	case "json":
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return false
+		}
+	case "dotenv":
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "sops_mac=") {
+				return true
+			}
+		}
+		return false
+	case "ini":
+		file, err := ini.Load(data)
+		return err == nil && file.Section("sops").HasKey("mac")
+	default:
+		return false
+	}
+
+	_, ok := doc.Sops["mac"]
+	return ok
+}
+
+// decryptSops decrypts the file in-process, age keys are read from SOPS_AGE_KEY_FILE or SOPS_AGE_KEY
+// and AWS KMS keys use the default AWS credential chain with the role from the sops metadata.
+func decryptSops(data []byte, format string) ([]byte, error) {
+	return decrypt.Data(data, format)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// unsetSopsAgeKey unsets SOPS_AGE_KEY for the test as it is used even when empty.
+func unsetSopsAgeKey(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY", "")
+	os.Unsetenv("SOPS_AGE_KEY")
+}
+
+// the fixtures in testdata are encrypted to the test age key in testdata/age.key
+func TestConvertFileConfigSops(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join("testdata", "age.key"))
+	unsetSopsAgeKey(t)
+
+	tests := []struct {
+		args map[string]interface{}
+		out  map[string]interface{}
+	}{
+		{
+			args: map[string]interface{}{"path": "testdata/secrets.enc.yaml"},
+			out:  map[string]interface{}{"password": "hunter2", "db.user": "app", "db": map[string]interface{}{"user": "app"}},
+		},
+		{
+			args: map[string]interface{}{"path": "testdata/secrets.enc.json"},
+			out:  map[string]interface{}{"password": "hunter2"},
+		},
+		{
+			args: map[string]interface{}{"path": "testdata/secrets.enc.env"},
+			out:  map[string]interface{}{"PASSWORD": "hunter2"},
+		},
+		{
+			args: map[string]interface{}{"path": "testdata/secrets.enc.yaml", "format": "yaml", "sops": true},
+			out:  map[string]interface{}{"password": "hunter2", "db.user": "app", "db": map[string]interface{}{"user": "app"}},
+		},
+	}
+
+	for _, test := range tests {
+		config := SourceConfig{Type: "File", Args: test.args}
+		out, err := convertFileConfig(&config)
+		if err != nil {
+			t.Errorf("%v: failed: %s", test.args, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("%v: got %#v, want %#v", test.args, out, test.out)
+		}
+
+		if !config.secret {
+			t.Errorf("%v: decrypted source should be marked secret", test.args)
+		}
+	}
+}
+
+func TestConvertFileConfigSopsWithoutKey(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(t.TempDir(), "missing.key"))
+	unsetSopsAgeKey(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	config := SourceConfig{Type: "File", Args: map[string]interface{}{"path": "testdata/secrets.enc.yaml"}}
+	if out, err := convertFileConfig(&config); err == nil {
+		t.Errorf("expected an error without the age key, got %v", out)
+	}
+}
+
+func TestConvertFileConfigPlain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.yaml")
+	if err := os.WriteFile(path, []byte("password: plain\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := SourceConfig{Type: "File", Args: map[string]interface{}{"path": path}}
+	out, err := convertFileConfig(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out["password"] != "plain" {
+		t.Errorf("got %#v, want plain", out)
+	}
+
+	if config.secret {
+		t.Errorf("plain file should not be marked secret")
+	}
+}
+
+func TestHasSopsMetadata(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+		sops   bool
+	}{
+		{data: "a: 1\nsops:\n  mac: x\n", format: "yaml", sops: true},
+		{data: "a: 1\nsops: {}\n", format: "yaml", sops: false},
+		{data: `{"sops": {"mac": "x"}}`, format: "json", sops: true},
+		{data: `{"a": 1}`, format: "json", sops: false},
+		{data: "A=1\nsops_mac=x\n", format: "dotenv", sops: true},
+		{data: "A=1\n", format: "dotenv", sops: false},
+		{data: "[sops]\nmac = x\n", format: "ini", sops: true},
+		{data: "a = 1\n", format: "ini", sops: false},
+		{data: "sops.mac=x\n", format: "properties", sops: false},
+	}
+
+	for _, test := range tests {
+		if sops := hasSopsMetadata([]byte(test.data), test.format); sops != test.sops {
+			t.Errorf("hasSopsMetadata(%q, %s) = %t, want %t", test.data, test.format, sops, test.sops)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultTerraformCloudHostname = "app.terraform.io"
+const defaultConsulAddress = "http://127.0.0.1:8500"
+
+// readTerraformState fetches the raw state from the configured backend, the default reads the path as a File source.
+func readTerraformState(config *SourceConfig) ([]byte, error) {
+	backend := getString(config.Args, "backend")
+	workspace := getString(config.Args, "workspace")
+
+	switch backend {
+	case "", "local", "s3":
+		path, err := terraformWorkspacePath(config, workspace)
+		if err != nil {
+			return nil, err
+		}
+		return readFile(withArgs(config, map[string]interface{}{"path": path}))
+	case "http":
+		address := getString(config.Args, "address")
+		if address == "" {
+			return nil, errors.New("address missing from http backend")
+		}
+		return readFile(withArgs(config, map[string]interface{}{"path": address}))
+	case "gcs":
+		return readGcsTerraformState(config, workspace)
+	case "consul":
+		return readConsulTerraformState(config, workspace)
+	case "remote", "cloud":
+		return readTerraformCloudState(config, workspace)
+	default:
+		return nil, errors.New("unsupported Terraform backend " + backend)
+	}
+}
+
+// withArgs returns a copy of the source config with some args replaced.
+func withArgs(config *SourceConfig, args map[string]interface{}) *SourceConfig {
+	out := *config
+	out.Args = make(map[string]interface{})
+	for k, v := range config.Args {
+		out.Args[k] = v
+	}
+	for k, v := range args {
+		out.Args[k] = v
+	}
+	return &out
+}
+
+// terraformWorkspacePath maps a state path to the path of a non-default workspace like the local and S3 backends do.
+func terraformWorkspacePath(config *SourceConfig, workspace string) (string, error) {
+	path := getString(config.Args, "path")
+	if path == "" {
+		return "", errors.New("path missing from TerraformState source")
+	}
+
+	if workspace == "" || workspace == "default" {
+		return path, nil
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "s3":
+		prefix := getString(config.Args, "workspaceKeyPrefix")
+		if prefix == "" {
+			prefix = "env:"
+		}
+		u.Path = "/" + prefix + "/" + workspace + u.Path
+		return u.String(), nil
+	case "":
+		return filepath.Join(filepath.Dir(path), "terraform.tfstate.d", workspace, filepath.Base(path)), nil
+	default:
+		return "", fmt.Errorf("workspaces are not supported for %s state paths", u.Scheme)
+	}
+}
+
+// readGcsTerraformState reads <bucket>/<prefix>/<workspace>.tfstate like the gcs backend.
+func readGcsTerraformState(config *SourceConfig, workspace string) ([]byte, error) {
+	bucket := getString(config.Args, "bucket")
+	if bucket == "" {
+		return nil, errors.New("bucket missing from gcs backend")
+	}
+
+	if workspace == "" {
+		workspace = "default"
+	}
+
+	object := workspace + ".tfstate"
+	if prefix := strings.Trim(getString(config.Args, "prefix"), "/"); prefix != "" {
+		object = prefix + "/" + object
+	}
+
+	return readGcsFile(&url.URL{Scheme: "gs", Host: bucket, Path: "/" + object})
+}
+
+// readConsulTerraformState reads the state from Consul KV, non-default workspaces are stored at <path>-env:<workspace>.
+func readConsulTerraformState(config *SourceConfig, workspace string) ([]byte, error) {
+	path := getString(config.Args, "path")
+	if path == "" {
+		return nil, errors.New("path missing from consul backend")
+	}
+
+	if workspace != "" && workspace != "default" {
+		path += "-env:" + workspace
+	}
+
+	address := getString(config.Args, "address")
+	if address == "" {
+		address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if address == "" {
+		address = defaultConsulAddress
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	token := getString(config.Args, "token")
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	headers := map[string]interface{}{}
+	if _, ok := config.Args["headers"]; ok {
+		for k, v := range getMap(config.Args, "headers") {
+			headers[k] = v
+		}
+	}
+	if token != "" {
+		headers["X-Consul-Token"] = token
+	}
+
+	data, err := readHttpFile(withArgs(config, map[string]interface{}{"headers": headers}), strings.TrimSuffix(address, "/")+"/v1/kv/"+strings.TrimPrefix(path, "/")+"?raw")
+	if err != nil {
+		return nil, err
+	}
+
+	// states written with gzip enabled are compressed
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return ioutil.ReadAll(reader)
+	}
+
+	return data, nil
+}
+
+// terraformCloudToken uses the token arg, TF_TOKEN_<hostname> like the Terraform CLI, or TFE_TOKEN.
+func terraformCloudToken(config *SourceConfig, hostname string) string {
+	if token := getString(config.Args, "token"); token != "" {
+		return token
+	}
+
+	env := "TF_TOKEN_" + strings.ReplaceAll(strings.ReplaceAll(hostname, "-", "__"), ".", "_")
+	if token := os.Getenv(env); token != "" {
+		return token
+	}
+
+	return os.Getenv("TFE_TOKEN")
+}
+
+// readTerraformCloudState downloads the current state version of a Terraform Cloud or Enterprise workspace.
+func readTerraformCloudState(config *SourceConfig, workspace string) ([]byte, error) {
+	organization := getString(config.Args, "organization")
+	if organization == "" || workspace == "" {
+		return nil, errors.New("organization and workspace are required for Terraform Cloud")
+	}
+
+	hostname := getString(config.Args, "hostname")
+	if hostname == "" {
+		hostname = defaultTerraformCloudHostname
+	}
+
+	token := terraformCloudToken(config, hostname)
+	if token == "" {
+		return nil, fmt.Errorf("no Terraform Cloud token found for %s", hostname)
+	}
+
+	// the state is not cached by ETag as the download URLs are short lived
+	api := withArgs(config, map[string]interface{}{"bearerToken": token, "bearerTokenEnv": ""})
+	api.cache = nil
+
+	base := "https://" + hostname
+	if strings.Contains(hostname, "://") {
+		base = strings.TrimSuffix(hostname, "/")
+	}
+
+	var ws struct {
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}
+
+	data, err := readHttpFile(api, fmt.Sprintf("%s/api/v2/organizations/%s/workspaces/%s", base, url.PathEscape(organization), url.PathEscape(workspace)))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform Cloud workspace: %w", err)
+	}
+
+	var stateVersion struct {
+		Data struct {
+			Attributes struct {
+				DownloadUrl string `json:"hosted-state-download-url"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+
+	data, err = readHttpFile(api, fmt.Sprintf("%s/api/v2/workspaces/%s/current-state-version", base, url.PathEscape(ws.Data.Id)))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &stateVersion); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform Cloud state version: %w", err)
+	}
+
+	if stateVersion.Data.Attributes.DownloadUrl == "" {
+		return nil, fmt.Errorf("workspace %s has no state to download", workspace)
+	}
+
+	return readHttpFile(api, stateVersion.Data.Attributes.DownloadUrl)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testTerraformState = `{"version": 4, "outputs": {"foo": {"value": "bar", "type": "string"}}}`
+
+func TestTerraformWorkspacePath(t *testing.T) {
+	tests := []struct {
+		args map[string]interface{}
+		path string
+		err  bool
+	}{
+		{args: map[string]interface{}{"path": "s3://bucket/app/terraform.tfstate"}, path: "s3://bucket/app/terraform.tfstate"},
+		{args: map[string]interface{}{"path": "s3://bucket/app/terraform.tfstate", "workspace": "default"}, path: "s3://bucket/app/terraform.tfstate"},
+		{args: map[string]interface{}{"path": "s3://bucket/app/terraform.tfstate", "workspace": "prod"}, path: "s3://bucket/env:/prod/app/terraform.tfstate"},
+		{args: map[string]interface{}{"path": "s3://bucket/app/terraform.tfstate", "workspace": "prod", "workspaceKeyPrefix": "workspaces"}, path: "s3://bucket/workspaces/prod/app/terraform.tfstate"},
+		{args: map[string]interface{}{"path": "state/terraform.tfstate", "workspace": "prod"}, path: "state/terraform.tfstate.d/prod/terraform.tfstate"},
+		{args: map[string]interface{}{"path": "https://example.com/terraform.tfstate", "workspace": "prod"}, err: true},
+		{args: map[string]interface{}{}, err: true},
+	}
+
+	for _, test := range tests {
+		config := SourceConfig{Type: "TerraformState", Args: test.args}
+		path, err := terraformWorkspacePath(&config, getString(test.args, "workspace"))
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %s", test.args, path)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: failed: %s", test.args, err)
+			continue
+		}
+
+		if path != test.path {
+			t.Errorf("%v: got %s, want %s", test.args, path, test.path)
+		}
+	}
+}
+
+func TestTerraformCloudToken(t *testing.T) {
+	t.Setenv("TFE_TOKEN", "fallback")
+	t.Setenv("TF_TOKEN_app_terraform_io", "")
+	t.Setenv("TF_TOKEN_my__tfe_example_com", "host")
+
+	tests := []struct {
+		args     map[string]interface{}
+		hostname string
+		token    string
+	}{
+		{args: map[string]interface{}{"token": "arg"}, hostname: "my-tfe.example.com", token: "arg"},
+		{args: map[string]interface{}{}, hostname: "my-tfe.example.com", token: "host"},
+		{args: map[string]interface{}{}, hostname: "app.terraform.io", token: "fallback"},
+	}
+
+	for _, test := range tests {
+		config := SourceConfig{Type: "TerraformState", Args: test.args}
+		if token := terraformCloudToken(&config, test.hostname); token != test.token {
+			t.Errorf("%v %s: got %s, want %s", test.args, test.hostname, token, test.token)
+		}
+	}
+}
+
+func TestConvertTerraformStateConfigCloud(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v2/organizations/org/workspaces/app":
+			fmt.Fprint(w, `{"data": {"id": "ws-123"}}`)
+		case "/api/v2/workspaces/ws-123/current-state-version":
+			fmt.Fprintf(w, `{"data": {"attributes": {"hosted-state-download-url": "%s/state/sv-456"}}}`, server.URL)
+		case "/state/sv-456":
+			fmt.Fprint(w, testTerraformState)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		args map[string]interface{}
+		err  bool
+	}{
+		{args: map[string]interface{}{"backend": "cloud", "organization": "org", "workspace": "app", "token": "token"}},
+		{args: map[string]interface{}{"backend": "remote", "organization": "org", "workspace": "app", "token": "token"}},
+		{args: map[string]interface{}{"backend": "cloud", "organization": "org", "workspace": "missing", "token": "token"}, err: true},
+		{args: map[string]interface{}{"backend": "cloud", "organization": "org", "workspace": "app", "token": "wrong"}, err: true},
+		{args: map[string]interface{}{"backend": "cloud", "organization": "org", "token": "token"}, err: true},
+	}
+
+	for _, test := range tests {
+		test.args["hostname"] = server.URL
+		out, err := convertTerraformStateConfig(&SourceConfig{Type: "TerraformState", Args: test.args})
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %v", test.args, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: failed: %s", test.args, err)
+			continue
+		}
+
+		if out["foo"] != "bar" {
+			t.Errorf("%v: got %#v", test.args, out)
+		}
+	}
+}
+
+func TestConvertTerraformStateConfigConsul(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(testTerraformState))
+	writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["raw"]; !ok || r.Header.Get("X-Consul-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/terraform/app":
+			fmt.Fprint(w, testTerraformState)
+		case "/v1/kv/terraform/app-env:prod":
+			w.Write(compressed.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("CONSUL_HTTP_ADDR", strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("CONSUL_HTTP_TOKEN", "token")
+
+	tests := []struct {
+		args map[string]interface{}
+		err  bool
+	}{
+		{args: map[string]interface{}{"backend": "consul", "path": "terraform/app"}},
+		{args: map[string]interface{}{"backend": "consul", "path": "terraform/app", "workspace": "prod"}},
+		{args: map[string]interface{}{"backend": "consul", "path": "terraform/app", "address": server.URL, "token": "token"}},
+		{args: map[string]interface{}{"backend": "consul", "path": "terraform/app", "workspace": "missing"}, err: true},
+		{args: map[string]interface{}{"backend": "consul", "path": "terraform/app", "token": "wrong"}, err: true},
+		{args: map[string]interface{}{"backend": "consul"}, err: true},
+	}
+
+	for _, test := range tests {
+		out, err := convertTerraformStateConfig(&SourceConfig{Type: "TerraformState", Args: test.args})
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected an error, got %v", test.args, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: failed: %s", test.args, err)
+			continue
+		}
+
+		if out["foo"] != "bar" {
+			t.Errorf("%v: got %#v", test.args, out)
+		}
+	}
+}
+
+func TestConvertTerraformStateConfigGcs(t *testing.T) {
+	server := newTestGcs(t, map[string]string{
+		"bucket/app/default.tfstate": testTerraformState,
+		"bucket/app/prod.tfstate":    testTerraformState,
+	})
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	for _, workspace := range []string{"", "prod"} {
+		out, err := convertTerraformStateConfig(&SourceConfig{Type: "TerraformState", Args: map[string]interface{}{
+			"backend": "gcs", "bucket": "bucket", "prefix": "/app/", "workspace": workspace,
+		}})
+		if err != nil {
+			t.Errorf("workspace '%s': failed: %s", workspace, err)
+			continue
+		}
+
+		if out["foo"] != "bar" {
+			t.Errorf("workspace '%s': got %#v", workspace, out)
+		}
+	}
+}
+
+func TestConvertTerraformStateConfigHttp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testTerraformState)
+	}))
+	t.Cleanup(server.Close)
+
+	out, err := convertTerraformStateConfig(&SourceConfig{Type: "TerraformState", Args: map[string]interface{}{
+		"backend": "http", "address": server.URL + "/state", "username": "user", "password": "pass",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out["foo"] != "bar" {
+		t.Errorf("got %#v", out)
+	}
+
+	if _, err := convertTerraformStateConfig(&SourceConfig{Type: "TerraformState", Args: map[string]interface{}{"backend": "artifactory"}}); err == nil {
+		t.Errorf("expected an error for an unsupported backend")
+	}
+}
@@ -1,13 +1,132 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"strings"
 )
 
+// terraformStateVersion is the state format written since Terraform 0.12
+const terraformStateVersion = 4
+
 type TerraformOutput struct {
-	Value interface{} `json:"value"`
-	Type  interface{} `json:"type"`
+	Value     interface{} `json:"value"`
+	Type      interface{} `json:"type"`
+	Sensitive bool        `json:"sensitive"`
+}
+
+// value returns the output converted by its declared type, sensitive outputs are marked.
+func (o *TerraformOutput) value() interface{} {
+	value := terraformValue(o.Value, o.Type)
+	if o.Sensitive {
+		return Sensitive{value}
+	}
+	return value
+}
+
+// terraformValue converts numbers in a JSON value by the declared Terraform type like "number" or ["list", "number"].
+func terraformValue(value interface{}, typ interface{}) interface{} {
+	switch t := typ.(type) {
+	case string:
+		switch t {
+		case "number":
+			if n, ok := value.(json.Number); ok {
+				return jsonNumber(n)
+			}
+		case "string", "bool":
+			return value
+		}
+	case []interface{}:
+		if len(t) != 2 {
+			break
+		}
+
+		kind, _ := t[0].(string)
+		switch v := value.(type) {
+		case []interface{}:
+			out := make([]interface{}, len(v))
+			for i, e := range v {
+				switch kind {
+				case "list", "set":
+					out[i] = terraformValue(e, t[1])
+				case "tuple":
+					types, _ := t[1].([]interface{})
+					if i < len(types) {
+						out[i] = terraformValue(e, types[i])
+					} else {
+						out[i] = jsonValue(e)
+					}
+				default:
+					out[i] = jsonValue(e)
+				}
+			}
+			return out
+		case map[string]interface{}:
+			attributes, _ := t[1].(map[string]interface{})
+			out := make(map[string]interface{})
+			for k, e := range v {
+				switch kind {
+				case "map":
+					out[k] = terraformValue(e, t[1])
+				case "object":
+					out[k] = terraformValue(e, attributes[k])
+				default:
+					out[k] = jsonValue(e)
+				}
+			}
+			return out
+		}
+	}
+
+	// dynamic or unknown types
+	return jsonValue(value)
+}
+
+// jsonValue converts the numbers of a JSON value decoded with UseNumber.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return jsonNumber(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = jsonValue(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, e := range v {
+			out[k] = jsonValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func jsonNumber(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n.String()
+}
+
+type TerraformResourceInstance struct {
+	IndexKey            interface{}            `json:"index_key"`
+	Attributes          map[string]interface{} `json:"attributes"`
+	SensitiveAttributes []json.RawMessage      `json:"sensitive_attributes"`
+}
+
+type TerraformResource struct {
+	Module    string                      `json:"module"`
+	Mode      string                      `json:"mode"`
+	Type      string                      `json:"type"`
+	Name      string                      `json:"name"`
+	Instances []TerraformResourceInstance `json:"instances"`
 }
 
 type TerraformState struct {
@@ -16,43 +135,151 @@ type TerraformState struct {
 	Serial           int                        `json:"serial"`
 	Lineage          string                     `json:"lineage"`
 	Outputs          map[string]TerraformOutput `json:"outputs"`
-	//Resources      []interface{}              `json:"resources"`
+	Resources        []TerraformResource        `json:"resources"`
+}
+
+// address returns the Terraform address of a resource instance like module.vpc.aws_subnet.private["a"].
+func (r *TerraformResource) address(instance *TerraformResourceInstance) string {
+	address := r.Type + "." + r.Name
+	if r.Mode == "data" {
+		address = "data." + address
+	}
+	if r.Module != "" {
+		address = r.Module + "." + address
+	}
+
+	switch key := instance.IndexKey.(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	case json.Number:
+		address += "[" + key.String() + "]"
+	}
+
+	return address
 }
 
-func convertTerraformStateConfig(config *SourceConfig) map[string]string {
-	data, err := readFile(config)
+// sensitivePaths converts the sensitive attribute paths of an instance to flattened keys.
+func (i *TerraformResourceInstance) sensitivePaths() []string {
+	var paths []string
+
+	for _, raw := range i.SensitiveAttributes {
+		var steps []struct {
+			Type  string      `json:"type"`
+			Value interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &steps); err != nil {
+			continue
+		}
+
+		var segments []string
+		for _, step := range steps {
+			switch step.Type {
+			case "get_attr":
+				segments = append(segments, toString(step.Value))
+			case "index":
+				// index values are typed as {"value": ..., "type": ...}
+				if typed, ok := step.Value.(map[string]interface{}); ok {
+					segments = append(segments, toString(typed["value"]))
+				}
+			}
+		}
+
+		if len(segments) > 0 {
+			paths = append(paths, strings.Join(segments, "."))
+		}
+	}
+
+	return paths
+}
+
+// isSensitiveKey checks if a key is a sensitive path, inside one or contains one.
+func isSensitiveKey(key string, sensitive []string) bool {
+	for _, path := range sensitive {
+		if key == path || strings.HasPrefix(key, path+".") || strings.HasPrefix(path, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenTerraformResources flattens instance attributes to resources.<address>.attributes.<attribute>.
+func flattenTerraformResources(resources []TerraformResource, out map[string]interface{}) {
+	for r := range resources {
+		resource := &resources[r]
+
+		for i := range resource.Instances {
+			instance := &resource.Instances[i]
+			sensitive := instance.sensitivePaths()
+
+			values := jsonValue(instance.Attributes)
+
+			attributes := make(map[string]interface{})
+			flattenToMap(values, "", attributes)
+
+			prefix := "resources." + resource.address(instance) + ".attributes"
+			for k, v := range attributes {
+				if isSensitiveKey(k, sensitive) {
+					v = Sensitive{v}
+				}
+				out[prefix+"."+k] = v
+			}
+
+			if len(sensitive) > 0 {
+				out[prefix] = Sensitive{values}
+			} else {
+				out[prefix] = values
+			}
+		}
+	}
+}
+
+func convertTerraformStateConfig(config *SourceConfig) (map[string]interface{}, error) {
+	data, err := readTerraformState(config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	tfstate := TerraformState{}
-	if err := json.Unmarshal(data, &tfstate); err != nil {
-		panic(err)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tfstate); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform state: %w", err)
+	}
+
+	if tfstate.Version != terraformStateVersion {
+		return nil, fmt.Errorf("unsupported Terraform state version %d, only version %d is supported", tfstate.Version, terraformStateVersion)
 	}
 
-	flat := make(map[string]string)
+	flat := make(map[string]interface{})
 
 	output := getString(config.Args, "output")
 	if output != "" {
 		if root, ok := tfstate.Outputs[output]; ok {
-			switch value := root.Value.(type) {
-			case map[interface{}]interface{}:
-				flattenToMap(value, "", flat)
-			case map[string]interface{}:
-				flattenToMap(value, "", flat)
-			default:
-				panic(errors.New("unsupported output type"))
+			value := root.value()
+			plain, _ := unwrapSensitive(value)
+			if _, ok := plain.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("unsupported type for output '%s'", output)
 			}
+			flattenToMap(value, "", flat)
 		} else {
-			panic(errors.New("could not find output key"))
+			return nil, fmt.Errorf("could not find output key '%s'", output)
 		}
 	} else {
 		raw := make(map[string]interface{})
 		for name, output := range tfstate.Outputs {
-			raw[name] = output.Value
+			raw[name] = output.value()
 		}
 		flattenToMap(raw, "", flat)
 	}
 
-	return flat
+	resources, err := getBool(config.Args, "resources", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if resources {
+		flattenTerraformResources(tfstate.Resources, flat)
+	}
+
+	return flat, nil
 }
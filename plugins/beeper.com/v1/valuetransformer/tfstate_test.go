@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodeJson decodes like the state is decoded, keeping numbers as json.Number.
+func decodeJson(t *testing.T, s string, v interface{}) {
+	t.Helper()
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		t.Fatalf("failed to decode %s: %s", s, err)
+	}
+}
+
+func TestTerraformResourceAddress(t *testing.T) {
+	tests := []struct {
+		resource string
+		address  string
+	}{
+		{resource: `{"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{}]}`, address: "aws_vpc.main"},
+		{resource: `{"mode": "data", "type": "aws_ami", "name": "ubuntu", "instances": [{}]}`, address: "data.aws_ami.ubuntu"},
+		{resource: `{"mode": "managed", "type": "aws_subnet", "name": "private", "instances": [{"index_key": 2}]}`, address: "aws_subnet.private[2]"},
+		{resource: `{"mode": "managed", "type": "aws_subnet", "name": "private", "instances": [{"index_key": "a"}]}`, address: `aws_subnet.private["a"]`},
+		{resource: `{"module": "module.vpc", "mode": "managed", "type": "aws_subnet", "name": "private", "instances": [{"index_key": "a"}]}`, address: `module.vpc.aws_subnet.private["a"]`},
+		{resource: `{"module": "module.vpc[0]", "mode": "data", "type": "aws_region", "name": "current", "instances": [{}]}`, address: "module.vpc[0].data.aws_region.current"},
+	}
+
+	for _, test := range tests {
+		var resource TerraformResource
+		decodeJson(t, test.resource, &resource)
+
+		if address := resource.address(&resource.Instances[0]); address != test.address {
+			t.Errorf("address of %s = %s, want %s", test.resource, address, test.address)
+		}
+	}
+}
+
+func TestFlattenTerraformResources(t *testing.T) {
+	var resources []TerraformResource
+	decodeJson(t, `[{
+		"mode": "managed",
+		"type": "aws_db_instance",
+		"name": "main",
+		"instances": [{
+			"index_key": 0,
+			"attributes": {"port": 5432, "password": "hunter2", "tags": {"env": "prod"}},
+			"sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]
+		}]
+	}, {
+		"mode": "managed",
+		"type": "aws_vpc",
+		"name": "main",
+		"instances": [{"attributes": {"cidr_block": "10.0.0.0/16"}}]
+	}]`, &resources)
+
+	out := make(map[string]interface{})
+	flattenTerraformResources(resources, out)
+
+	expected := map[string]interface{}{
+		"resources.aws_db_instance.main[0].attributes.port":     int64(5432),
+		"resources.aws_db_instance.main[0].attributes.password": Sensitive{"hunter2"},
+		"resources.aws_db_instance.main[0].attributes.tags.env": "prod",
+		"resources.aws_vpc.main.attributes.cidr_block":          "10.0.0.0/16",
+	}
+
+	for k, v := range expected {
+		if !reflect.DeepEqual(out[k], v) {
+			t.Errorf("%s = %#v, want %#v", k, out[k], v)
+		}
+	}
+
+	if _, ok := out["resources.aws_db_instance.main[0].attributes"].(Sensitive); !ok {
+		t.Errorf("attributes with a sensitive attribute should be sensitive, got %#v", out["resources.aws_db_instance.main[0].attributes"])
+	}
+
+	if _, ok := out["resources.aws_vpc.main.attributes"].(Sensitive); ok {
+		t.Errorf("attributes without sensitive attributes should not be sensitive")
+	}
+}
+
+func TestTerraformValue(t *testing.T) {
+	tests := []struct {
+		value string
+		typ   string
+		out   interface{}
+	}{
+		{value: `5`, typ: `"number"`, out: int64(5)},
+		{value: `1.5`, typ: `"number"`, out: 1.5},
+		{value: `"5"`, typ: `"string"`, out: "5"},
+		{value: `true`, typ: `"bool"`, out: true},
+		{value: `[1, 2]`, typ: `["list", "number"]`, out: []interface{}{int64(1), int64(2)}},
+		{value: `["a"]`, typ: `["set", "string"]`, out: []interface{}{"a"}},
+		{value: `["a", 1, [2]]`, typ: `["tuple", ["string", "number"]]`, out: []interface{}{"a", int64(1), []interface{}{int64(2)}}},
+		{value: `{"a": 1}`, typ: `["map", "number"]`, out: map[string]interface{}{"a": int64(1)}},
+		{value: `{"a": "x", "b": [1]}`, typ: `["object", {"a": "string", "b": ["list", "number"]}]`, out: map[string]interface{}{"a": "x", "b": []interface{}{int64(1)}}},
+		{value: `{"a": 1.25}`, typ: `"dynamic"`, out: map[string]interface{}{"a": 1.25}},
+		{value: `7`, typ: `null`, out: int64(7)},
+	}
+
+	for _, test := range tests {
+		var value, typ interface{}
+		decodeJson(t, test.value, &value)
+		decodeJson(t, test.typ, &typ)
+
+		if out := terraformValue(value, typ); !reflect.DeepEqual(out, test.out) {
+			t.Errorf("terraformValue(%s, %s) = %#v, want %#v", test.value, test.typ, out, test.out)
+		}
+	}
+}
+
+func TestTerraformOutputValue(t *testing.T) {
+	var outputs map[string]TerraformOutput
+	decodeJson(t, `{
+		"port": {"value": 8080, "type": "number"},
+		"password": {"value": "hunter2", "type": "string", "sensitive": true}
+	}`, &outputs)
+
+	port := outputs["port"]
+	if value := port.value(); value != int64(8080) {
+		t.Errorf("port = %#v, want 8080", value)
+	}
+
+	password := outputs["password"]
+	if value := password.value(); value != (Sensitive{"hunter2"}) {
+		t.Errorf("password = %#v, want sensitive hunter2", value)
+	}
+}
@@ -2,44 +2,150 @@ package main
 
 import (
 	"regexp"
+
+	"gopkg.in/yaml.v3"
 )
 
 type Transform struct {
-	regex  *regexp.Regexp
-	source map[string]string
-	match  map[string]bool
+	regex   *regexp.Regexp
+	sources []map[string]interface{}
+	strict  bool
+	typed   bool
+	allow   []string
+
+	// sensitive values are only substituted into Secrets unless allowed
+	allowSensitive bool
+
+	fieldPaths        [][]string
+	excludeFieldPaths [][]string
+
+	// pending holds the misses of the field being transformed, misses are those left in a field after all transforms
+	pending []string
+	misses  []Miss
+}
+
+// Miss is a match that was left unresolved in a field.
+type Miss struct {
+	Match string
+	Field string
 }
 
 type ResourceList struct {
 	Kind           string                   `yaml:"kind"`
 	Items          []map[string]interface{} `yaml:"items"`
 	FunctionConfig TransformerConfig        `yaml:"functionConfig"`
+	Results        []Result                 `yaml:"results,omitempty"`
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+type ResourceRef struct {
+	ApiVersion string `yaml:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty"`
+	Name       string `yaml:"name,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty"`
+}
+
+type Field struct {
+	Path string `yaml:"path"`
+}
+
+type Result struct {
+	Message     string       `yaml:"message"`
+	Severity    string       `yaml:"severity,omitempty"`
+	ResourceRef *ResourceRef `yaml:"resourceRef,omitempty"`
+	Field       *Field       `yaml:"field,omitempty"`
 }
 
 type Selector struct {
-	Kind      string `yaml:"kind"`
-	Name      string `yaml:"name"`
-	Namespace string `yaml:"namespace"`
+	Group              string `yaml:"group"`
+	Version            string `yaml:"version"`
+	Kind               string `yaml:"kind"`
+	Name               string `yaml:"name"`
+	Namespace          string `yaml:"namespace"`
+	LabelSelector      string `yaml:"labelSelector"`
+	AnnotationSelector string `yaml:"annotationSelector"`
+}
+
+type EncodedField struct {
+	Kind     string `yaml:"kind"`
+	Path     string `yaml:"path"`
+	Encoding string `yaml:"encoding"`
+
+	segments []string
 }
 
 type TransformConfig struct {
-	Source string   `yaml:"source"`
-	Regex  string   `yaml:"regex"`
-	Target Selector `yaml:"target"`
+	Source string `yaml:"source"`
+	// Sources are looked up in order after Source
+	Sources []string `yaml:"sources"`
+	Regex   string   `yaml:"regex"`
+	Target  Selector `yaml:"target"`
+	// Targets are matched in addition to Target
+	Targets         []Selector `yaml:"targets"`
+	Strict          *bool      `yaml:"strict"`
+	AllowUnresolved []string   `yaml:"allowUnresolved"`
+	Typed           bool       `yaml:"typed"`
+	// FieldPaths and ExcludeFieldPaths limit the transform to matching fields and their children
+	FieldPaths        []string `yaml:"fieldPaths"`
+	ExcludeFieldPaths []string `yaml:"excludeFieldPaths"`
+	// AllowSensitive allows sensitive values in resources other than Secrets
+	AllowSensitive bool `yaml:"allowSensitive"`
 }
 
 type SourceConfig struct {
-	Type string                 `yaml:"type"`
-	Args map[string]interface{} `yaml:"args"`
-	Vars map[string]interface{} `yaml:"vars"` // filter and remap source data
+	Type  string                 `yaml:"type"`
+	Args  map[string]interface{} `yaml:"args"`
+	Vars  map[string]interface{} `yaml:"vars"`  // filter and remap source data
+	Cache *bool                  `yaml:"cache"` // override default caching of remote sources
+	// Override replaces a source with the same name from an include instead of failing
+	Override bool `yaml:"override"`
+
+	cache  *SourceCache // used for ETag revalidation of remote files
+	secret bool         // set by sources that decrypted their data
+}
+
+type CacheConfig struct {
+	Dir string `yaml:"dir"`
+	Ttl string `yaml:"ttl"`
+}
+
+// Include is a local or remote file path, optionally pinned to the sha256 of its content
+type Include struct {
+	Path   string                 `yaml:"path"`
+	Sha256 string                 `yaml:"sha256,omitempty"`
+	Args   map[string]interface{} `yaml:"args,omitempty"`
+}
+
+// UnmarshalYAML accepts both a plain path string and a mapping.
+func (i *Include) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&i.Path)
+	}
+
+	type plain Include
+	return node.Decode((*plain)(i))
 }
 
 type TransformerConfig struct {
 	ApiVersion string                  `yaml:"apiVersion"`
 	Kind       string                  `yaml:"kind"`
-	Includes   []string                `yaml:"includes"`
+	Includes   []Include               `yaml:"includes"`
 	Sources    map[string]SourceConfig `yaml:"sources"`
 	Merges     map[string]interface{}  `yaml:"merges"`
 	Transforms []TransformConfig       `yaml:"transforms"`
 	Excludes   []Selector              `yaml:"excludes"`
+	// EncodedFields lists maps whose values are decoded before and encoded after transforming
+	EncodedFields []EncodedField `yaml:"encodedFields"`
+	Cache         CacheConfig    `yaml:"cache"`
+	// Strict fails the build on unresolved matches unless they are allowed
+	Strict          bool     `yaml:"strict"`
+	AllowUnresolved []string `yaml:"allowUnresolved"`
+
+	// sourceDepth is the include depth sources were merged from
+	sourceDepth map[string]int
 }
@@ -7,10 +7,15 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// defaultTransformRegex groups are: (sourceKey) (defaultEnabledFlag :) (defaultValue), both the key and the default
+// stop at } or : unless they are inside double quoted filter arguments like ${url | default "https://host"}.
+var defaultTransformRegex = regexp.MustCompile(`\${((?:[^}:"]|"(?:[^"\\]|\\.)*")*)(:?)((?:[^}:"]|"(?:[^"\\]|\\.)*")*)}`)
+
 func getString(r map[string]interface{}, key string) string {
 	i, ok := r[key]
 	if !ok {
@@ -47,18 +52,69 @@ func getMap(r map[string]interface{}, key string) map[string]interface{} {
 	}
 }
 
-func flattenToMap(i interface{}, path string, out map[string]string) {
+func getInt(r map[string]interface{}, key string) (int, error) {
+	switch v := r[key].(type) {
+	case int:
+		return v, nil
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer for '%s': %s", key, v)
+		}
+		return i, nil
+	default:
+		return 0, nil
+	}
+}
+
+func getBool(r map[string]interface{}, key string, def bool) (bool, error) {
+	switch v := r[key].(type) {
+	case bool:
+		return v, nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return def, fmt.Errorf("invalid boolean for '%s': %s", key, v)
+		}
+		return b, nil
+	default:
+		return def, nil
+	}
+}
+
+func getStringList(r map[string]interface{}, key string) []string {
+	switch v := r[key].(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return []string{v}
+	default:
+		return nil
+	}
+}
+
+func flattenToMap(i interface{}, path string, out map[string]interface{}) {
 	flattenToMapWithJsonify(i, path, out, true)
 }
 
-func flattenToMapWithJsonify(i interface{}, path string, out map[string]string, jsonify bool) {
+// flattenToMapWithJsonify keeps the original typed values, with jsonify lists and maps are also
+// stored at their own path and are converted to JSON when used as a string.
+func flattenToMapWithJsonify(i interface{}, path string, out map[string]interface{}, jsonify bool) {
 	switch v := i.(type) {
-	case nil:
-		out[path] = ""
-	case string:
+	case nil, string, bool, int, int64, float32, float64:
 		out[path] = v
-	case bool, int, int64, float32, float64:
-		out[path] = fmt.Sprintf("%v", v)
 	case []interface{}:
 		for i, v := range v {
 			k := strconv.Itoa(i)
@@ -70,9 +126,7 @@ func flattenToMapWithJsonify(i interface{}, path string, out map[string]string,
 		}
 
 		if path != "" && jsonify {
-			if data, err := json.Marshal(v); err == nil {
-				out[path] = string(data)
-			}
+			out[path] = v
 		}
 	case map[interface{}]interface{}:
 		for k, v := range v {
@@ -89,8 +143,8 @@ func flattenToMapWithJsonify(i interface{}, path string, out map[string]string,
 		}
 
 		if path != "" && jsonify {
-			if data, err := json.Marshal(v); err == nil {
-				out[path] = string(data)
+			if _, err := json.Marshal(v); err == nil {
+				out[path] = v
 			}
 		}
 	case map[string]interface{}:
@@ -103,9 +157,14 @@ func flattenToMapWithJsonify(i interface{}, path string, out map[string]string,
 		}
 
 		if path != "" && jsonify {
-			if data, err := json.Marshal(v); err == nil {
-				out[path] = string(data)
-			}
+			out[path] = v
+		}
+	case Sensitive:
+		// everything flattened from a sensitive value is sensitive
+		flat := make(map[string]interface{})
+		flattenToMapWithJsonify(v.Value, path, flat, jsonify)
+		for k, fv := range flat {
+			out[k] = Sensitive{fv}
 		}
 	default:
 		fmt.Fprintf(os.Stderr, "Unhandled type during flattening: %T, defaulting to %%v\n", v)
@@ -113,12 +172,31 @@ func flattenToMapWithJsonify(i interface{}, path string, out map[string]string,
 	}
 }
 
-func filterMap(in map[string]string, filter map[string]string) map[string]string {
+// toString converts a flattened value to its string form, lists and maps are converted to JSON.
+func toString(i interface{}) string {
+	switch v := i.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool, int, int64, float32, float64:
+		return fmt.Sprintf("%v", v)
+	case Sensitive:
+		return toString(v.Value)
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func filterMap(in map[string]interface{}, filter map[string]interface{}) map[string]interface{} {
 	if len(filter) > 0 {
-		out := make(map[string]string)
+		out := make(map[string]interface{})
 		for k, v := range filter {
 			if ov, ok := in[k]; ok {
-				out[v] = ov
+				out[toString(v)] = ov
 			}
 		}
 		return out
@@ -135,6 +213,12 @@ func expandEnvInterface(i interface{}) interface{} {
 			out[k] = expandEnvInterface(v)
 		}
 		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, v := range t {
+			out[k] = expandEnvInterface(v)
+		}
+		return out
 	case []interface{}:
 		out := make([]interface{}, len(t))
 		for k, v := range t {
@@ -143,110 +227,271 @@ func expandEnvInterface(i interface{}) interface{} {
 		return out
 	case string:
 		return os.ExpandEnv(t)
+	case bool, int, float64, nil:
 	default:
 		fmt.Fprintf(os.Stderr, "Unhandled type during expanding environment: %T, ignored\n", t)
 	}
 	return i
 }
 
-func transformInterface(i interface{}, transforms []Transform, path string) interface{} {
+// recordMisses keeps the pending misses of a field that are still in its transformed value.
+func recordMisses(transforms []Transform, path []string, out string) {
+	for i := range transforms {
+		transform := &transforms[i]
+		for _, match := range transform.pending {
+			if strings.Contains(out, match) {
+				transform.misses = append(transform.misses, Miss{Match: match, Field: fieldPath(path)})
+			}
+		}
+		transform.pending = nil
+	}
+}
+
+func transformInterface(i interface{}, transforms []Transform, encoded []EncodedField, path []string) (interface{}, error) {
 	switch t := i.(type) {
 	case map[string]interface{}:
 		out := make(map[string]interface{})
 		for k, v := range t {
-			out[k] = transformInterface(v, transforms, path+"/"+k)
+			tv, err := transformInterface(v, transforms, encoded, childPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = tv
 		}
-		return out
+		return out, nil
 	case []interface{}:
 		out := make([]interface{}, len(t))
 		for k, v := range t {
-			out[k] = transformInterface(v, transforms, "")
+			tv, err := transformInterface(v, transforms, encoded, childPath(path, indexSegment(k)))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = tv
 		}
-		return out
+		return out, nil
 	case map[interface{}]interface{}:
 		out := make(map[interface{}]interface{})
 		for k, v := range t {
+			var tv interface{}
+			var err error
 			switch kt := k.(type) {
 			case string:
-				out[k] = transformInterface(v, transforms, path+"/"+kt)
+				tv, err = transformInterface(v, transforms, encoded, childPath(path, kt))
 			default:
-				out[k] = transformInterface(v, transforms, "")
+				tv, err = transformInterface(v, transforms, encoded, childPath(path, fmt.Sprintf("%v", k)))
+			}
+			if err != nil {
+				return nil, err
 			}
+			out[k] = tv
 		}
-		return out
+		return out, nil
 	case string:
 		var out string
 		b64encode := false
 
-		// FIXME: ugly way to handle encoded secrets
-		if strings.HasPrefix(path, "Secret/data/") {
+		if fieldEncoding(encoded, path) == EncodingBase64 {
 			if decoded, err := base64.StdEncoding.DecodeString(t); err == nil {
 				out = string(decoded)
 				b64encode = true
 			} else {
-				panic(err)
+				return nil, &ResultError{Result{
+					Message:  fmt.Sprintf("value is not valid base64: %s", err),
+					Severity: SeverityError,
+					Field:    &Field{Path: fieldPath(path)},
+				}}
 			}
 		} else {
 			out = t
 		}
 
-		for i := range transforms {
-			transform := &transforms[i]
+		// a value that is a single match is replaced with the typed source value
+		if !b64encode {
+			for i := range transforms {
+				transform := &transforms[i]
+				if !transform.typed || !transform.appliesTo(path) {
+					continue
+				}
 
-			out = transform.regex.ReplaceAllStringFunc(out, func(sk string) string {
-				matches := transform.regex.FindStringSubmatch(sk)
-				if len(matches) < 2 {
-					return sk
+				loc := transform.regex.FindStringIndex(out)
+				if loc == nil || loc[0] != 0 || loc[1] != len(out) {
+					continue
 				}
 
-				repl, foundRepl := transform.source[matches[1]]
+				repl, found, err := transform.resolve(out, path)
+				if err != nil {
+					return nil, err
+				}
 
-				if !foundRepl && len(matches) > 2 && len(matches[2]) > 0 {
-					repl = matches[3]
-					foundRepl = true
+				if found {
+					if _, isString := repl.(string); !isString {
+						recordMisses(transforms, path, "")
+						return repl, nil
+					}
 				}
+			}
+		}
 
-				// update matched state for string if it doesn't exist or we found
-				matched, havePrevMatch := transform.match[matches[0]]
-				if !havePrevMatch || (foundRepl && !matched) {
-					transform.match[matches[0]] = foundRepl
+		var resolveErr error
+		for i := range transforms {
+			transform := &transforms[i]
+			if !transform.appliesTo(path) {
+				continue
+			}
+
+			out = transform.regex.ReplaceAllStringFunc(out, func(sk string) string {
+				repl, found, err := transform.resolve(sk, path)
+				if err != nil && resolveErr == nil {
+					resolveErr = err
 				}
 
-				if !foundRepl {
+				if !found {
 					return sk
 				}
 
-				return repl
+				return toString(repl)
 			})
 		}
 
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+
+		recordMisses(transforms, path, out)
+
 		if b64encode {
 			out = base64.StdEncoding.EncodeToString([]byte(out))
 		}
 
-		return out
+		return out, nil
 	default:
 		if DebugEnabled {
 			fmt.Fprintf(os.Stderr, "Unhandled type during transforming: %T, ignored\n", t)
 		}
 	}
-	return i
+	return i, nil
 }
 
-func applyTransforms(resource map[string]interface{}, config *TransformerConfig, sources map[string]map[string]string) map[string]interface{} {
-	kind := getString(resource, "kind")
-	metadata := getMap(resource, "metadata")
-	name := getString(metadata, "name")
-	namespace := getString(metadata, "namespace")
+// lookup finds the key from the first source that has it.
+func (t *Transform) lookup(key string) (interface{}, bool, error) {
+	for _, source := range t.sources {
+		if value, ok := source[key]; ok {
+			value, sensitive := unwrapSensitive(value)
+			if sensitive && !t.allowSensitive {
+				return nil, false, fmt.Errorf("'%s' is sensitive and can only be used in Secrets unless allowSensitive is set", key)
+			}
+			return value, true, nil
+		}
+	}
+	return nil, false, nil
+}
 
-	for _, e := range config.Excludes {
-		if e.Kind != "" && e.Kind != kind {
-			continue
+// resolve looks up the value for a regex match through its filter pipeline and records it as pending if not found.
+func (t *Transform) resolve(sk string, path []string) (interface{}, bool, error) {
+	matches := t.regex.FindStringSubmatch(sk)
+	if len(matches) < 2 {
+		return nil, false, nil
+	}
+
+	key, pipeline, err := splitPipeline(matches[1])
+	if err != nil {
+		return nil, false, t.resolveError(matches[0], path, err)
+	}
+
+	repl, foundRepl, err := t.lookup(key)
+	if err != nil {
+		return nil, false, t.resolveError(matches[0], path, err)
+	}
+
+	if len(matches) > 3 && len(matches[2]) > 0 {
+		// filters can also follow the default value
+		defaultValue, defaultPipeline, err := splitPipeline(matches[3])
+		if err != nil {
+			return nil, false, t.resolveError(matches[0], path, err)
 		}
-		if e.Name != "" && e.Name != name {
-			continue
+
+		pipeline = append(pipeline, defaultPipeline...)
+
+		if !foundRepl {
+			repl = defaultValue
+			foundRepl = true
+		}
+	}
+
+	if len(pipeline) > 0 {
+		if repl, foundRepl, err = applyFilters(toString(repl), foundRepl, pipeline); err != nil {
+			return nil, false, t.resolveError(matches[0], path, err)
 		}
-		if e.Namespace != "" && e.Namespace != namespace {
+	}
+
+	if !foundRepl {
+		t.pending = append(t.pending, matches[0])
+	}
+
+	return repl, foundRepl, nil
+}
+
+func (t *Transform) resolveError(match string, path []string, err error) error {
+	return &ResultError{Result{
+		Message:  fmt.Sprintf("ValueTransform match '%s' failed: %s", match, err),
+		Severity: SeverityError,
+		Field:    &Field{Path: fieldPath(path)},
+	}}
+}
+
+// appliesTo checks if the transform is scoped to the field path.
+func (t *Transform) appliesTo(path []string) bool {
+	if len(t.fieldPaths) > 0 && !matchAnyFieldPath(t.fieldPaths, path) {
+		return false
+	}
+
+	return !matchAnyFieldPath(t.excludeFieldPaths, path)
+}
+
+// allowed checks if an unresolved match or its key is allowed to be left in place.
+func (t *Transform) allowed(match string) bool {
+	key := ""
+	if matches := t.regex.FindStringSubmatch(match); len(matches) > 1 {
+		key, _, _ = splitPipeline(matches[1])
+	}
+
+	for _, allow := range t.allow {
+		if allow == match || allow == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sourceNames returns the source followed by the fallback sources in lookup order.
+func (t *TransformConfig) sourceNames() []string {
+	names := []string{}
+	if t.Source != "" {
+		names = append(names, t.Source)
+	}
+	return append(names, t.Sources...)
+}
+
+func applyTransforms(resource map[string]interface{}, config *TransformerConfig, sources map[string]map[string]interface{}) (map[string]interface{}, []Result, error) {
+	meta := newResourceMeta(resource)
+	stripAnnotations(resource)
+
+	kind := meta.kind
+	name := meta.name
+	namespace := meta.namespace
+
+	ref := &ResourceRef{
+		ApiVersion: meta.apiVersion,
+		Kind:       kind,
+		Name:       name,
+		Namespace:  namespace,
+	}
+
+	for _, e := range config.Excludes {
+		if match, err := e.matches(meta); err != nil {
+			return nil, nil, err
+		} else if !match {
 			continue
 		}
 
@@ -254,80 +499,204 @@ func applyTransforms(resource map[string]interface{}, config *TransformerConfig,
 			fmt.Fprintf(os.Stderr, "Filtered out %s/%s in %s from transformations\n", kind, name, namespace)
 		}
 
-		return resource
+		return resource, nil, nil
+	}
+
+	if skip, err := annotationSkip(meta); err != nil {
+		return nil, nil, &ResultError{Result{Message: err.Error(), Severity: SeverityError, ResourceRef: ref}}
+	} else if skip {
+		if DebugEnabled {
+			fmt.Fprintf(os.Stderr, "Skipped %s/%s in %s from transformations by annotation\n", kind, name, namespace)
+		}
+
+		return resource, nil, nil
+	}
+
+	transformConfigs := config.Transforms
+	if t := annotationTransform(meta); t != nil {
+		transformConfigs = append(append([]TransformConfig{}, config.Transforms...), *t)
 	}
 
 	transforms := []Transform{}
 
-	for _, t := range config.Transforms {
-		if t.Target.Kind != "" && t.Target.Kind != kind {
-			continue
-		}
-		if t.Target.Name != "" && t.Target.Name != name {
+	for _, t := range transformConfigs {
+		target, matched, err := t.matchTarget(meta)
+		if err != nil {
+			return nil, nil, err
+		} else if !matched {
 			continue
 		}
-		if t.Target.Namespace != "" && t.Target.Namespace != namespace {
-			continue
+
+		sourceNames := t.sourceNames()
+		transformSources := make([]map[string]interface{}, len(sourceNames))
+		for i, sourceName := range sourceNames {
+			if transformSources[i] = sources[sourceName]; transformSources[i] == nil {
+				return nil, nil, &ResultError{Result{
+					Message:     "unknown source " + sourceName,
+					Severity:    SeverityError,
+					ResourceRef: ref,
+				}}
+			}
 		}
 
-		source := sources[t.Source]
-		if source == nil {
-			panic(errors.New("Unknown source " + t.Source))
+		if len(transformSources) == 0 {
+			return nil, nil, errors.New("transform has no source")
 		}
 
 		var regex *regexp.Regexp
 		if t.Regex == "" {
-			// Groups are: (sourceKey) (defaultEnabledFlag :) (defaultValue)
-			regex = regexp.MustCompile(`\${([^}:]*)(:?)([^}:]*)}`)
+			regex = defaultTransformRegex
 		} else {
-			regex = regexp.MustCompile(t.Regex)
+			var err error
+			if regex, err = regexp.Compile(t.Regex); err != nil {
+				return nil, nil, fmt.Errorf("invalid regex for source '%s': %w", strings.Join(sourceNames, ","), err)
+			}
 		}
 
-		transforms = append(transforms, Transform{regex, source, make(map[string]bool)})
+		fieldPaths, err := parseFieldPaths(t.FieldPaths)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		excludeFieldPaths, err := parseFieldPaths(t.ExcludeFieldPaths)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		strict := config.Strict
+		if t.Strict != nil {
+			strict = *t.Strict
+		}
+
+		transforms = append(transforms, Transform{
+			regex:   regex,
+			sources: transformSources,
+			strict:  strict,
+			typed:   t.Typed,
+
+			allowSensitive: t.AllowSensitive || kind == "Secret",
+
+			fieldPaths:        fieldPaths,
+			excludeFieldPaths: excludeFieldPaths,
+			allow:             append(append([]string{}, config.AllowUnresolved...), t.AllowUnresolved...),
+		})
 
 		if DebugEnabled {
-			fmt.Fprintf(os.Stderr, "Enabled transform regex '%s' with source '%s' to %s/%s (target was %s/%s in %s)\n", regex.String(), t.Source, kind, name, t.Target.Kind, t.Target.Name, t.Target.Namespace)
+			fmt.Fprintf(os.Stderr, "Enabled transform regex '%s' with source '%s' to %s/%s (target %d was %s/%s in %s)\n", regex.String(), strings.Join(sourceNames, ","), kind, name, target, t.targets()[target].Kind, t.targets()[target].Name, t.targets()[target].Namespace)
 		}
 	}
 
-	ret := transformInterface(resource, transforms, kind).(map[string]interface{})
+	encoded, err := encodedFieldsFor(config, kind)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ret, err := transformInterface(resource, transforms, encoded, nil)
+	if err != nil {
+		var resultErr *ResultError
+		if errors.As(err, &resultErr) {
+			resultErr.ResourceRef = ref
+		}
+		return nil, nil, err
+	}
 
-	misses := make(map[string]struct{})
+	// a miss is strict if any strict transform left it unresolved
+	strictMisses := make(map[Miss]bool)
 	for i := range transforms {
 		transform := &transforms[i]
 
-		for match, found := range transform.match {
-			if !found {
-				misses[match] = struct{}{}
-			} else {
-				delete(misses, match)
-			}
+		for _, miss := range transform.misses {
+			strict := transform.strict && !transform.allowed(miss.Match)
+			strictMisses[miss] = strictMisses[miss] || strict
 		}
 	}
 
-	if len(misses) > 0 {
-		for missed := range misses {
-			fmt.Fprintf(os.Stderr, "Warning: ValueTransform match '%s' not found for resource %s/%s in namespace %s\n", missed, kind, name, namespace)
+	missed := make([]Miss, 0, len(strictMisses))
+	for miss := range strictMisses {
+		missed = append(missed, miss)
+	}
+	sort.Slice(missed, func(i, j int) bool {
+		if missed[i].Match != missed[j].Match {
+			return missed[i].Match < missed[j].Match
+		}
+		return missed[i].Field < missed[j].Field
+	})
+
+	results := []Result{}
+	for _, miss := range missed {
+		result := Result{
+			Message:     fmt.Sprintf("ValueTransform match '%s' not found", miss.Match),
+			Severity:    SeverityWarning,
+			ResourceRef: ref,
 		}
+		if strictMisses[miss] {
+			result.Severity = SeverityError
+		}
+		if miss.Field != "" {
+			result.Field = &Field{Path: miss.Field}
+		}
+		results = append(results, result)
 	}
 
-	return ret
+	return ret.(map[string]interface{}), results, nil
 }
 
-func mergeConfig(dst *TransformerConfig, src *TransformerConfig) error {
+// overrideSource checks if an included source replaces an existing one, the source of the file closer to the root
+// config wins when it sets override and at the same depth an override replaces a plain source. Sources of the root
+// config are never replaced and an include can't override a closer file.
+func overrideSource(name string, existing SourceConfig, existingDepth int, source SourceConfig, depth int) (bool, error) {
+	switch {
+	case existingDepth == depth && existing.Override && source.Override:
+		return false, fmt.Errorf("included file has conflicting override for source: %s", name)
+	case existingDepth == depth && (existing.Override || source.Override):
+		return source.Override, nil
+	case existingDepth < depth && existing.Override:
+		return false, nil
+	case existingDepth > depth && source.Override:
+		return true, nil
+	case existingDepth == 0 && source.Override:
+		return false, fmt.Errorf("included file can not override source %s of the root config, set override in the root config instead", name)
+	case existing.Override || source.Override:
+		return false, fmt.Errorf("included file can not override source %s of a file closer to the root config", name)
+	default:
+		return false, fmt.Errorf("included file has duplicate source: %s, set override to replace it", name)
+	}
+}
+
+// mergeConfig merges an included config at the given include depth, the root config is at depth 0.
+// A source with override replaces the same source from another file, when both override the one closer to the root wins.
+func mergeConfig(dst *TransformerConfig, src *TransformerConfig, depth int) error {
 	dst.Includes = append(dst.Includes, src.Includes...)
 
 	if src.Sources != nil {
 		if dst.Sources == nil {
 			dst.Sources = map[string]SourceConfig{}
 		}
+		if dst.sourceDepth == nil {
+			dst.sourceDepth = map[string]int{}
+		}
 
 		for k, v := range src.Sources {
-			if _, found := dst.Sources[k]; found {
-				return fmt.Errorf("included file has duplicate source: %s", k)
+			if existing, found := dst.Sources[k]; found {
+				replace, err := overrideSource(k, existing, dst.sourceDepth[k], v, depth)
+				if err != nil {
+					return err
+				}
+
+				if !replace {
+					if DebugEnabled {
+						fmt.Fprintf(os.Stderr, "Source '%s' from include is overridden\n", k)
+					}
+					continue
+				}
+
+				if DebugEnabled {
+					fmt.Fprintf(os.Stderr, "Source '%s' is overridden by include\n", k)
+				}
 			}
 
 			dst.Sources[k] = v
+			dst.sourceDepth[k] = depth
 		}
 	}
 
@@ -347,6 +716,16 @@ func mergeConfig(dst *TransformerConfig, src *TransformerConfig) error {
 
 	dst.Transforms = append(dst.Transforms, src.Transforms...)
 	dst.Excludes = append(dst.Excludes, src.Excludes...)
+	if dst.Cache.Dir == "" {
+		dst.Cache.Dir = src.Cache.Dir
+	}
+	if dst.Cache.Ttl == "" {
+		dst.Cache.Ttl = src.Cache.Ttl
+	}
+
+	dst.EncodedFields = append(dst.EncodedFields, src.EncodedFields...)
+	dst.Strict = dst.Strict || src.Strict
+	dst.AllowUnresolved = append(dst.AllowUnresolved, src.AllowUnresolved...)
 
 	return nil
 }
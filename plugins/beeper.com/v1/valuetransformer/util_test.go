@@ -0,0 +1,270 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyTestTransforms parses the resource and config from YAML and applies the transforms.
+func applyTestTransforms(t *testing.T, resource string, config string, sources map[string]map[string]interface{}) (map[string]interface{}, []Result, error) {
+	t.Helper()
+
+	var res map[string]interface{}
+	if err := yaml.Unmarshal([]byte(resource), &res); err != nil {
+		t.Fatalf("invalid resource: %s", err)
+	}
+
+	var cfg TransformerConfig
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		t.Fatalf("invalid config: %s", err)
+	}
+
+	return applyTransforms(res, &cfg, sources)
+}
+
+// resultSummary reduces results to their severity, message and field for comparison.
+func resultSummary(results []Result) []string {
+	out := []string{}
+	for _, result := range results {
+		summary := result.Severity + " " + result.Message
+		if result.Field != nil {
+			summary += " at " + result.Field.Path
+		}
+		out = append(out, summary)
+	}
+	return out
+}
+
+func TestApplyTransformsStrict(t *testing.T) {
+	sources := map[string]map[string]interface{}{
+		"vars":  {"a": "1"},
+		"other": {"b": "2"},
+	}
+
+	resource := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  a: ${a}
+  b: ${b}
+  c: ${c} and ${HOME}
+`
+
+	tests := []struct {
+		name    string
+		config  string
+		results []string
+	}{
+		{
+			name:   "warnings by default",
+			config: "transforms: [{source: vars}]",
+			results: []string{
+				"warning ValueTransform match '${HOME}' not found at data.c",
+				"warning ValueTransform match '${b}' not found at data.b",
+				"warning ValueTransform match '${c}' not found at data.c",
+			},
+		},
+		{
+			name:   "strict",
+			config: "strict: true\ntransforms: [{source: vars}]",
+			results: []string{
+				"error ValueTransform match '${HOME}' not found at data.c",
+				"error ValueTransform match '${b}' not found at data.b",
+				"error ValueTransform match '${c}' not found at data.c",
+			},
+		},
+		{
+			name:   "allowed by key and match",
+			config: "strict: true\nallowUnresolved: [HOME]\ntransforms: [{source: vars, allowUnresolved: ['${c}']}]",
+			results: []string{
+				"warning ValueTransform match '${HOME}' not found at data.c",
+				"error ValueTransform match '${b}' not found at data.b",
+				"warning ValueTransform match '${c}' not found at data.c",
+			},
+		},
+		{
+			name:   "transform disables strict",
+			config: "strict: true\ntransforms: [{source: vars, strict: false}]",
+			results: []string{
+				"warning ValueTransform match '${HOME}' not found at data.c",
+				"warning ValueTransform match '${b}' not found at data.b",
+				"warning ValueTransform match '${c}' not found at data.c",
+			},
+		},
+		{
+			name:   "resolved by another transform",
+			config: "strict: true\ntransforms: [{source: vars}, {source: other}]",
+			results: []string{
+				"error ValueTransform match '${HOME}' not found at data.c",
+				"error ValueTransform match '${c}' not found at data.c",
+			},
+		},
+		{
+			name:   "strict if any transform is strict",
+			config: "transforms: [{source: vars, strict: true}, {source: other}]",
+			results: []string{
+				"error ValueTransform match '${HOME}' not found at data.c",
+				"error ValueTransform match '${c}' not found at data.c",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, results, err := applyTestTransforms(t, resource, test.config, sources)
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		if summary := resultSummary(results); !reflect.DeepEqual(summary, test.results) {
+			t.Errorf("%s: got results %q, want %q", test.name, summary, test.results)
+		}
+
+		if a := getMap(out, "data")["a"]; a != "1" {
+			t.Errorf("%s: got a %v, want 1", test.name, a)
+		}
+	}
+}
+
+func TestApplyTransformsMissPerField(t *testing.T) {
+	// a match resolved in one field by a scoped transform is still missing in another field
+	resource := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  a: ${x}
+  b: ${x}
+`
+
+	config := `
+strict: true
+transforms:
+  - source: vars
+    fieldPaths: [data.a]
+  - source: empty
+`
+
+	sources := map[string]map[string]interface{}{
+		"vars":  {"x": "1"},
+		"empty": {},
+	}
+
+	out, results, err := applyTestTransforms(t, resource, config, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"error ValueTransform match '${x}' not found at data.b"}
+	if summary := resultSummary(results); !reflect.DeepEqual(summary, expected) {
+		t.Errorf("got results %q, want %q", summary, expected)
+	}
+
+	if data := getMap(out, "data"); data["a"] != "1" || data["b"] != "${x}" {
+		t.Errorf("got %v", data)
+	}
+
+	if len(results) > 0 && (results[0].ResourceRef == nil || results[0].ResourceRef.Kind != "ConfigMap" || results[0].ResourceRef.Name != "app") {
+		t.Errorf("result is missing the resource reference: %#v", results[0].ResourceRef)
+	}
+}
+
+func TestApplyTransformsUnknownSource(t *testing.T) {
+	resource := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"
+
+	_, _, err := applyTestTransforms(t, resource, "transforms: [{source: missing}]", map[string]map[string]interface{}{})
+
+	resultErr, ok := err.(*ResultError)
+	if !ok {
+		t.Fatalf("expected a result error, got %v", err)
+	}
+
+	if resultErr.Severity != SeverityError || resultErr.ResourceRef == nil || resultErr.ResourceRef.Name != "app" {
+		t.Errorf("got %#v", resultErr.Result)
+	}
+}
+
+func TestApplyTransformsTyped(t *testing.T) {
+	sources := map[string]map[string]interface{}{
+		"vars": {
+			"replicas": 3,
+			"enabled":  true,
+			"ratio":    0.5,
+			"name":     "app",
+			"ports":    []interface{}{80, 443},
+			"labels":   map[string]interface{}{"team": "payments"},
+		},
+	}
+
+	resource := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: ${replicas}
+  enabled: ${enabled}
+  ratio: ${ratio}
+  name: ${name}
+  ports: ${ports}
+  labels: ${labels}
+  text: replicas ${replicas}
+  port: ${ports | default "x"}
+`
+
+	tests := []struct {
+		name   string
+		config string
+		spec   map[string]interface{}
+	}{
+		{
+			name:   "typed",
+			config: "transforms: [{source: vars, typed: true}]",
+			spec: map[string]interface{}{
+				"replicas": 3,
+				"enabled":  true,
+				"ratio":    0.5,
+				"name":     "app",
+				"ports":    []interface{}{80, 443},
+				"labels":   map[string]interface{}{"team": "payments"},
+				"text":     "replicas 3",
+				"port":     "[80,443]",
+			},
+		},
+		{
+			name:   "untyped",
+			config: "transforms: [{source: vars}]",
+			spec: map[string]interface{}{
+				"replicas": "3",
+				"enabled":  "true",
+				"ratio":    "0.5",
+				"name":     "app",
+				"ports":    "[80,443]",
+				"labels":   `{"team":"payments"}`,
+				"text":     "replicas 3",
+				"port":     "[80,443]",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, results, err := applyTestTransforms(t, resource, test.config, sources)
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		if len(results) > 0 {
+			t.Errorf("%s: unexpected results %q", test.name, resultSummary(results))
+		}
+
+		if spec := getMap(out, "spec"); !reflect.DeepEqual(spec, test.spec) {
+			t.Errorf("%s: got %#v, want %#v", test.name, spec, test.spec)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+const defaultKubernetesJwtPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+func vaultLogin(client *vault.Client, config *SourceConfig) error {
+	authMethod := getString(config.Args, "authMethod")
+	authMount := getString(config.Args, "authMount")
+	if authMount == "" {
+		authMount = authMethod
+	}
+
+	var data map[string]interface{}
+
+	switch authMethod {
+	case "", "token":
+		// VAULT_TOKEN is picked up by the client, args override it
+		if token := getString(config.Args, "token"); token != "" {
+			client.SetToken(token)
+		}
+		return nil
+	case "approle":
+		data = map[string]interface{}{
+			"role_id":   getString(config.Args, "roleId"),
+			"secret_id": getString(config.Args, "secretId"),
+		}
+	case "kubernetes":
+		jwtPath := getString(config.Args, "jwtPath")
+		if jwtPath == "" {
+			jwtPath = defaultKubernetesJwtPath
+		}
+
+		jwt, err := os.ReadFile(jwtPath)
+		if err != nil {
+			return err
+		}
+
+		data = map[string]interface{}{
+			"role": getString(config.Args, "role"),
+			"jwt":  string(jwt),
+		}
+	default:
+		return errors.New("unsupported Vault auth method " + authMethod)
+	}
+
+	secret, err := client.Logical().Write("auth/"+authMount+"/login", data)
+	if err != nil {
+		return err
+	}
+
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("no token returned from Vault %s login", authMethod)
+	}
+
+	client.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+func convertVaultConfig(config *SourceConfig) (map[string]interface{}, error) {
+	path := getString(config.Args, "path")
+	if len(path) == 0 {
+		return nil, errors.New("no secret path given")
+	}
+
+	mount := getString(config.Args, "mount")
+	if mount == "" {
+		mount = "secret"
+	}
+
+	// VAULT_ADDR and friends are read by the default config
+	vaultConfig := vault.DefaultConfig()
+	if vaultConfig.Error != nil {
+		return nil, vaultConfig.Error
+	}
+
+	if address := getString(config.Args, "address"); address != "" {
+		vaultConfig.Address = address
+	}
+
+	client, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace := getString(config.Args, "namespace"); namespace != "" {
+		client.SetNamespace(namespace)
+	}
+
+	if err := vaultLogin(client, config); err != nil {
+		return nil, err
+	}
+
+	kvVersion, err := getInt(config.Args, "kvVersion")
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := getInt(config.Args, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	var secret *vault.KVSecret
+	ctx := context.Background()
+
+	switch kvVersion {
+	case 1:
+		secret, err = client.KVv1(mount).Get(ctx, path)
+	case 0, 2:
+		if version > 0 {
+			secret, err = client.KVv2(mount).GetVersion(ctx, path, version)
+		} else {
+			secret, err = client.KVv2(mount).Get(ctx, path)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Vault KV version %d", kvVersion)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+	flattenToMap(secret.Data, "", out)
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testVaultToken = "s.test"
+
+// newTestVault serves an approle login, a KV v2 secret at secret/app and a KV v1 secret at kv/app.
+func newTestVault(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	reply := func(w http.ResponseWriter, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var login map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login["role_id"] != "role" || login["secret_id"] != "secret" {
+			http.Error(w, `{"errors": ["invalid role or secret ID"]}`, http.StatusBadRequest)
+			return
+		}
+		reply(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": testVaultToken}})
+	})
+	mux.HandleFunc("/v1/secret/data/app", func(w http.ResponseWriter, r *http.Request) {
+		password := "latest"
+		if r.URL.Query().Get("version") == "1" {
+			password = "first"
+		}
+		reply(w, map[string]interface{}{"data": map[string]interface{}{
+			"data":     map[string]interface{}{"password": password, "db": map[string]interface{}{"user": "app"}},
+			"metadata": map[string]interface{}{"version": 2},
+		}})
+	})
+	mux.HandleFunc("/v1/kv/app", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]interface{}{"data": map[string]interface{}{"password": "v1"}})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" && r.Header.Get("X-Vault-Token") != testVaultToken {
+			http.Error(w, `{"errors": ["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestConvertVaultConfig(t *testing.T) {
+	server := newTestVault(t)
+	t.Setenv("VAULT_TOKEN", "")
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		out  map[string]interface{}
+		err  bool
+	}{
+		{
+			name: "token kv2",
+			args: map[string]interface{}{"path": "app", "token": testVaultToken},
+			out:  map[string]interface{}{"password": "latest", "db.user": "app", "db": map[string]interface{}{"user": "app"}},
+		},
+		{
+			name: "approle kv2 version",
+			args: map[string]interface{}{"path": "app", "authMethod": "approle", "roleId": "role", "secretId": "secret", "version": 1},
+			out:  map[string]interface{}{"password": "first", "db.user": "app", "db": map[string]interface{}{"user": "app"}},
+		},
+		{
+			name: "token kv1",
+			args: map[string]interface{}{"path": "app", "mount": "kv", "kvVersion": 1, "token": testVaultToken},
+			out:  map[string]interface{}{"password": "v1"},
+		},
+		{
+			name: "bad approle",
+			args: map[string]interface{}{"path": "app", "authMethod": "approle", "roleId": "role", "secretId": "wrong"},
+			err:  true,
+		},
+		{
+			name: "no token",
+			args: map[string]interface{}{"path": "app"},
+			err:  true,
+		},
+		{
+			name: "no path",
+			args: map[string]interface{}{"token": testVaultToken},
+			err:  true,
+		},
+		{
+			name: "unsupported auth",
+			args: map[string]interface{}{"path": "app", "authMethod": "ldap"},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test.args["address"] = server.URL
+		out, err := convertVaultConfig(&SourceConfig{Type: "Vault", Args: test.args})
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", test.name, out)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: failed: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("%s: got %#v, want %#v", test.name, out, test.out)
+		}
+	}
+}